@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Approval states reported by the server.
+const (
+	ApprovalPending  = "pending"  // Waiting for a reviewer.
+	ApprovalApproved = "approved" // Approved and applied by the server.
+	ApprovalRejected = "rejected" // Rejected by a reviewer.
+	ApprovalFailed   = "failed"   // Approved, but the deploy failed.
+	ApprovalExpired  = "expired"  // Not reviewed in time.
+)
+
+// Approval is a deploy waiting for, or given, the approval of a second person.
+type Approval struct {
+	ID          string    `json:"id"`                   // The ID of the request.
+	Release     string    `json:"release"`              // The application/chart name to deploy.
+	Namespace   string    `json:"namespace"`            // The namespace to deploy.
+	VersionTag  string    `json:"versionTag"`           // The docker version tag.
+	Memo        string    `json:"memo,omitempty"`       // The memo of the deploy.
+	Requester   string    `json:"requester"`            // The user of the token that requested the deploy.
+	RequestedAt time.Time `json:"requestedAt"`          // When the deploy was requested.
+	Status      string    `json:"status"`               // pending, approved, rejected, failed or expired.
+	Reviewer    string    `json:"reviewer,omitempty"`   // The user of the token that reviewed the deploy.
+	ReviewedAt  time.Time `json:"reviewedAt,omitempty"` // When the deploy was approved or rejected.
+	Reason      string    `json:"reason,omitempty"`     // Why the deploy was rejected.
+	Message     string    `json:"message,omitempty"`    // The result of applying the deploy.
+}
+
+// ReviewRequest is the payload to approve or reject a deploy.
+type ReviewRequest struct {
+	Reason string `json:"reason,omitempty"` // Why the deploy is rejected.
+}
+
+// RequestApproval submits a deploy to wait for approval instead of applying it. The server
+// records the user of the bearer token as the requester. The message of the response is the
+// Approval created.
+func (c *Client) RequestApproval(name string, versionTag string, namespace string, memo string) (*Response, error) {
+	payload, err := json.Marshal(&DeployRequest{
+		Memo:       memo,
+		Name:       name,
+		Namespace:  namespace,
+		VersionTag: versionTag,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(httpPost, fmt.Sprintf("%s%s", c.Url, httpRouteApprovals), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	return c.sendRequest(req, "approvals", httpRouteApprovalsVersion)
+}
+
+// Approvals returns the approval requests of a namespace, or all if empty, with a status, or
+// any if empty.
+func (c *Client) Approvals(namespace string, status string) ([]Approval, error) {
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteApprovals), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("n", namespace)
+	q.Add("s", status)
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	resp, err := c.sendRequest(req, "approvals", httpRouteApprovalsVersion)
+	if err != nil {
+		return nil, err
+	}
+	var approvals []Approval
+	if err := resp.Decode(&approvals); err != nil {
+		return nil, fmt.Errorf("decode approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// Approval returns an approval request.
+func (c *Client) Approval(id string) (*Approval, error) {
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteApproval, id)), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	resp, err := c.sendRequest(req, "approvals", httpRouteApprovalsVersion)
+	if err != nil {
+		return nil, err
+	}
+	var a Approval
+	if err := resp.Decode(&a); err != nil {
+		return nil, fmt.Errorf("decode approval: %w", err)
+	}
+	return &a, nil
+}
+
+// Approve approves a deploy as the user of the bearer token. The server applies it and refuses
+// approval by the requester.
+func (c *Client) Approve(id string) (*Response, error) {
+	return c.review(httpRouteApprovalApprove, id, &ReviewRequest{})
+}
+
+// Reject rejects a deploy as the user of the bearer token.
+func (c *Client) Reject(id string, reason string) (*Response, error) {
+	return c.review(httpRouteApprovalReject, id, &ReviewRequest{Reason: reason})
+}
+
+// WaitForApproval polls an approval request until it is no longer pending or the timeout expires.
+func (c *Client) WaitForApproval(id string, timeout time.Duration) (*Approval, error) {
+	poll := c.polling()
+	deadline := time.Now().Add(timeout)
+	for {
+		a, err := poll.Approval(id)
+		if err == nil && a.Status != ApprovalPending {
+			return a, nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return nil, err
+			}
+			return a, fmt.Errorf("%w waiting for approval %s", ErrTimeout, id)
+		}
+		time.Sleep(approvalWaitInterval)
+	}
+}
+
+// review sends an approve or reject request.
+func (c *Client) review(route string, id string, rr *ReviewRequest) (*Response, error) {
+	payload, err := json.Marshal(rr)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(httpPut, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(route, id)), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	return c.sendRequest(req, "approvals", httpRouteApprovalsVersion)
+}
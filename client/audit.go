@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit results.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry is one line of the audit log, recorded for every mutating command.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`                // When the command started.
+	User       string    `json:"user"`                // The local user running the command.
+	Cluster    string    `json:"cluster"`             // The cluster the command ran against.
+	Namespace  string    `json:"namespace,omitempty"` // The namespace, if known.
+	Command    string    `json:"command"`             // The command, ex: k8ctl releases deploy.
+	Args       []string  `json:"args"`                // The arguments and flags as typed.
+	RequestID  string    `json:"requestID,omitempty"` // The X-Request-ID sent to the server.
+	Status     string    `json:"status,omitempty"`    // The status returned by the server.
+	Result     string    `json:"result"`              // success or failure.
+	Error      string    `json:"error,omitempty"`     // The error of a failure.
+	DurationMs int64     `json:"durationMs"`          // How long the command took.
+}
+
+// AuditFilter selects entries when searching the audit log. Empty fields match everything.
+type AuditFilter struct {
+	Since     time.Time // Only entries at or after this time.
+	User      string    // Only entries by this user.
+	Cluster   string    // Only entries against this cluster.
+	Namespace string    // Only entries in this namespace.
+	Command   string    // Only entries whose command contains this text.
+	RequestID string    // Only the entry with this request ID.
+	Result    string    // Only entries with this result.
+}
+
+// Match returns true if the entry is selected by the filter.
+func (f *AuditFilter) Match(e *AuditEntry) bool {
+	switch {
+	case !f.Since.IsZero() && e.Time.Before(f.Since),
+		f.User != "" && e.User != f.User,
+		f.Cluster != "" && e.Cluster != f.Cluster,
+		f.Namespace != "" && e.Namespace != f.Namespace,
+		f.Command != "" && !strings.Contains(e.Command, f.Command),
+		f.RequestID != "" && !strings.EqualFold(e.RequestID, f.RequestID),
+		f.Result != "" && e.Result != f.Result:
+		return false
+	}
+	return true
+}
+
+// AuditLog is an append only JSONL file of audit entries. When the file grows past MaxSize it
+// is rotated to Path.1, Path.1 to Path.2 and so on, keeping MaxBackups old files.
+type AuditLog struct {
+	Path       string // The path of the current log file.
+	MaxSize    int64  // Size in bytes before the file is rotated.
+	MaxBackups int    // Number of rotated files to keep.
+	mu         sync.Mutex
+}
+
+// NewAuditLog is a factory function that returns a new audit log.
+func NewAuditLog(path string, maxSize int64, maxBackups int) *AuditLog {
+	return &AuditLog{
+		Path:       path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+	}
+}
+
+// Append writes an entry to the log, rotating it first if needed.
+func (a *AuditLog) Append(e *AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.Path), 0700); err != nil {
+		return err
+	}
+	if err := a.rotate(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// Search returns the entries selected by the filter, oldest first, across the rotated files.
+func (a *AuditLog) Search(f *AuditFilter) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var result []AuditEntry
+	for i := a.MaxBackups; i >= 0; i-- {
+		path := a.Path
+		if i > 0 {
+			path = fmt.Sprintf("%s.%d", a.Path, i)
+		}
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var e AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue // Skip damaged lines.
+			}
+			if f.Match(&e) {
+				result = append(result, e)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// rotate shifts the log files if the current one is too large.
+func (a *AuditLog) rotate() error {
+	info, err := os.Stat(a.Path)
+	if os.IsNotExist(err) || (err == nil && info.Size() < a.MaxSize) || a.MaxSize <= 0 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if a.MaxBackups <= 0 {
+		return os.Remove(a.Path)
+	}
+	os.Remove(fmt.Sprintf("%s.%d", a.Path, a.MaxBackups))
+	for i := a.MaxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", a.Path, i), fmt.Sprintf("%s.%d", a.Path, i+1))
+	}
+	return os.Rename(a.Path, a.Path+".1")
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Plan is a set of releases deployed together by "releases deploy-batch".
+type Plan struct {
+	Namespace string        `yaml:"namespace" json:"namespace,omitempty"` // Default namespace of the releases.
+	Memo      string        `yaml:"memo" json:"memo,omitempty"`           // Memo sent with every deploy.
+	Releases  []PlanRelease `yaml:"releases" json:"releases"`             // The releases to deploy.
+}
+
+// PlanRelease is a release of a plan and the releases that must be healthy before it deploys.
+type PlanRelease struct {
+	Name      string   `yaml:"name" json:"name"`                     // The chart or release name.
+	Tag       string   `yaml:"tag" json:"tag"`                       // The docker version tag.
+	Namespace string   `yaml:"namespace" json:"namespace,omitempty"` // Namespace if not the plan default.
+	DependsOn []string `yaml:"dependsOn" json:"dependsOn,omitempty"` // Names of releases deployed first.
+	Memo      string   `yaml:"memo" json:"memo,omitempty"`           // Memo if not the plan default.
+}
+
+// LoadPlan reads and validates a plan from a YAML file.
+func LoadPlan(path string) (*Plan, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Plan
+	if err := yaml.UnmarshalStrict(b, &p); err != nil {
+		return nil, fmt.Errorf("plan %s: %s", path, err)
+	}
+	for i := range p.Releases {
+		r := &p.Releases[i]
+		if r.Namespace == "" {
+			r.Namespace = p.Namespace
+		}
+		if r.Memo == "" {
+			r.Memo = p.Memo
+		}
+	}
+	if _, err := p.Stages(); err != nil {
+		return nil, fmt.Errorf("plan %s: %s", path, err)
+	}
+	return &p, nil
+}
+
+// Stages orders the releases by their dependencies. Releases of a stage depend only on
+// releases of earlier stages, so each stage can be deployed in parallel.
+func (p *Plan) Stages() ([][]PlanRelease, error) {
+	if len(p.Releases) == 0 {
+		return nil, fmt.Errorf("no releases")
+	}
+	index := map[string]int{}
+	for i, r := range p.Releases {
+		switch {
+		case r.Name == "" || r.Tag == "":
+			return nil, fmt.Errorf("release %d requires a name and tag", i+1)
+		case r.Namespace == "":
+			return nil, fmt.Errorf("release %s requires a namespace", r.Name)
+		}
+		if _, ok := index[r.Name]; ok {
+			return nil, fmt.Errorf("release %s is listed twice", r.Name)
+		}
+		index[r.Name] = i
+	}
+	pending := map[string]int{} // Dependencies not yet in a stage.
+	for _, r := range p.Releases {
+		for _, d := range r.DependsOn {
+			if _, ok := index[d]; !ok {
+				return nil, fmt.Errorf("release %s depends on %s which is not in the plan", r.Name, d)
+			}
+		}
+		pending[r.Name] = len(r.DependsOn)
+	}
+
+	var stages [][]PlanRelease
+	done := 0
+	for done < len(p.Releases) {
+		var stage []PlanRelease
+		for _, r := range p.Releases {
+			if n, ok := pending[r.Name]; ok && n == 0 {
+				stage = append(stage, r)
+			}
+		}
+		if len(stage) == 0 {
+			var cycle []string
+			for name := range pending {
+				cycle = append(cycle, name)
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("dependency cycle between %v", cycle)
+		}
+		for _, s := range stage {
+			delete(pending, s.Name)
+			for _, r := range p.Releases {
+				for _, d := range r.DependsOn {
+					if d == s.Name {
+						pending[r.Name]--
+					}
+				}
+			}
+		}
+		done += len(stage)
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanStages(t *testing.T) {
+	release := func(name string, dependsOn ...string) PlanRelease {
+		return PlanRelease{Name: name, Tag: "k8-1.0.0-1", Namespace: "dev", DependsOn: dependsOn}
+	}
+	tests := []struct {
+		name     string
+		releases []PlanRelease
+		want     string // Stages as names separated by spaces, stages by |.
+		err      string
+	}{
+		{"independent", []PlanRelease{release("a"), release("b"), release("c")}, "a b c", ""},
+		{"chain", []PlanRelease{release("c", "b"), release("b", "a"), release("a")}, "a|b|c", ""},
+		{"diamond", []PlanRelease{release("d", "b", "c"), release("b", "a"), release("c", "a"), release("a")},
+			"a|b c|d", ""},
+		{"missing dependency", []PlanRelease{release("a", "db")}, "", "depends on db which is not in the plan"},
+		{"cycle", []PlanRelease{release("a", "c"), release("b", "a"), release("c", "b"), release("d")}, "",
+			"dependency cycle between [a b c]"},
+		{"self", []PlanRelease{release("a", "a")}, "", "dependency cycle between [a]"},
+		{"duplicate", []PlanRelease{release("a"), release("a")}, "", "listed twice"},
+		{"no tag", []PlanRelease{{Name: "a", Namespace: "dev"}}, "", "requires a name and tag"},
+		{"no namespace", []PlanRelease{{Name: "a", Tag: "k8-1.0.0-1"}}, "", "requires a namespace"},
+		{"empty", nil, "", "no releases"},
+	}
+	for _, tt := range tests {
+		p := Plan{Releases: tt.releases}
+		stages, err := p.Stages()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, stage := range stages {
+			var names []string
+			for _, r := range stage {
+				names = append(names, r.Name)
+			}
+			got = append(got, strings.Join(names, " "))
+		}
+		if strings.Join(got, "|") != tt.want {
+			t.Errorf("%s: stages = %q, want %q", tt.name, strings.Join(got, "|"), tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long a response is served from the cache without asking the server,
+// for resources without a TTL of their own.
+const DefaultCacheTTL = 10 * time.Second
+
+// defaultCacheTTLs are the TTLs of resources that change at another pace than most. A TTL of
+// zero revalidates the response with the server on every use.
+var defaultCacheTTLs = map[string]time.Duration{
+	"guide":     24 * time.Hour,
+	"approvals": 0,
+	"freezes":   0, // Checked before every mutating command.
+	"metrics":   0,
+	"rollouts":  0,
+}
+
+// uncachedResources are never stored, ex: because a response may hold revealed secrets.
+var uncachedResources = map[string]bool{
+	"discovery": true, // Cached by Discovery.Save.
+	"secrets":   true,
+}
+
+// Cache stores the GET responses of a server on disk, keyed by route, query and API version.
+// A response is served without asking the server while younger than the TTL of its resource,
+// and revalidated with its ETag afterwards. Successful changes invalidate the responses they
+// make stale.
+type Cache struct {
+	Dir  string                   // The directory of the responses of one cluster.
+	TTL  time.Duration            // The TTL of resources not in TTLs.
+	TTLs map[string]time.Duration // TTLs by resource, ex: pods: 5s (optional).
+
+	Revalidate bool // Ask the server for every response, ex: with --no-cache or while polling.
+}
+
+// NewCache returns a cache of the responses stored in dir, with the default TTLs overridden
+// by ttls.
+func NewCache(dir string, ttl time.Duration, ttls map[string]time.Duration) *Cache {
+	c := &Cache{Dir: dir, TTL: ttl, TTLs: make(map[string]time.Duration)}
+	for r, d := range defaultCacheTTLs {
+		c.TTLs[r] = d
+	}
+	for r, d := range ttls {
+		c.TTLs[r] = d
+	}
+	return c
+}
+
+// cacheEntry is a response stored in the cache.
+type cacheEntry struct {
+	URL    string    `json:"url"`            // The URL requested.
+	ETag   string    `json:"etag,omitempty"` // The ETag of the response, if the server sent one.
+	Stored time.Time `json:"stored"`         // When the response was received or revalidated.
+	Body   []byte    `json:"body"`           // The body of the response.
+}
+
+// ttl returns the TTL of a resource.
+func (c *Cache) ttl(resource string) time.Duration {
+	if c.Revalidate {
+		return 0
+	}
+	if d, ok := c.TTLs[resource]; ok {
+		return d
+	}
+	return c.TTL
+}
+
+// revalidating returns a copy of the cache that revalidates every response, for polling.
+func (c *Cache) revalidating() *Cache {
+	if c == nil {
+		return nil
+	}
+	cc := *c
+	cc.Revalidate = true
+	return &cc
+}
+
+// path returns the file of a response. Responses are grouped by resource for invalidation.
+func (c *Cache) path(resource string, apiVersion string, req *http.Request) string {
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s %s %s", apiVersion, req.URL.Path, req.URL.Query().Encode())))
+	return filepath.Join(c.Dir, resource, fmt.Sprintf("%x.json", key[:16]))
+}
+
+// load returns the stored response of a request, nil if there is none.
+func (c *Cache) load(path string) *cacheEntry {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil
+	}
+	return &e
+}
+
+// fresh returns true if a stored response can be used without asking the server.
+func (c *Cache) fresh(resource string, e *cacheEntry) bool {
+	return time.Since(e.Stored) < c.ttl(resource)
+}
+
+// store saves a response. Responses that could never be reused are not stored.
+func (c *Cache) store(path string, resource string, e *cacheEntry) {
+	if e.ETag == "" && c.ttl(resource) <= 0 {
+		return
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	// A temporary file of its own keeps concurrent writers from mixing their responses.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// cacheable returns true if the responses of a request may be stored.
+func (c *Cache) cacheable(req *http.Request, resource string) bool {
+	return c != nil && req.Method == http.MethodGet && !uncachedResources[resource]
+}
+
+// Invalidate removes the stored responses of resources.
+func (c *Cache) Invalidate(resources ...string) error {
+	for _, r := range resources {
+		if err := os.RemoveAll(filepath.Join(c.Dir, r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidateAfter removes the responses made stale by a successful request to change a
+// resource at a URL path. Releases, rollouts and approved deploys change every kind of resource
+// of a namespace, requesting or rejecting a deploy only changes the approvals, and restarts
+// change the pods and their metrics. A dry run changes nothing.
+func (c *Cache) invalidateAfter(resource string, path string) {
+	switch {
+	case strings.HasSuffix(path, httpRouteReleaseDryRun):
+	case resource == "approvals" && !strings.HasSuffix(path, "/approve"):
+		c.Invalidate(resource)
+	case resource == "releases", resource == "rollouts", resource == "approvals":
+		c.Clear()
+	case resource == "freezes":
+		c.Invalidate(resource)
+	default:
+		c.Invalidate(resource, "pods", "metrics")
+	}
+}
+
+// Clear removes every stored response.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
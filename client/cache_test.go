@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheFreshResponse(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"1"`)
+		fmt.Fprint(w, `{"status":"OK","message":"the guide"}`)
+	}))
+	defer srv.Close()
+	cl := NewClient(srv.URL, "token")
+	cl.Cache = NewCache(t.TempDir(), time.Hour, nil)
+
+	first, err := cl.Guide()
+	if err != nil {
+		t.Fatalf("Guide: %s", err)
+	}
+	second, err := cl.Guide()
+	if err != nil {
+		t.Fatalf("Guide from the cache: %s", err)
+	}
+	if requests != 1 {
+		t.Errorf("server asked %d times, want once", requests)
+	}
+	if second.Message != first.Message {
+		t.Errorf("cached message = %q, want %q", second.Message, first.Message)
+	}
+	if second.RequestID == "" || second.RequestID == first.RequestID {
+		t.Errorf("cached RequestID = %q, want a new one (first was %q)", second.RequestID, first.RequestID)
+	}
+}
+
+func TestCacheKeptByDryRun(t *testing.T) {
+	gets := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+		}
+		w.Header().Set("ETag", `"1"`)
+		fmt.Fprint(w, `{"status":"OK","message":"{}"}`)
+	}))
+	defer srv.Close()
+	cl := NewClient(srv.URL, "token")
+	cl.Cache = NewCache(t.TempDir(), time.Hour, nil)
+
+	if _, err := cl.Guide(); err != nil {
+		t.Fatalf("Guide: %s", err)
+	}
+	if _, err := cl.DeployDryRun("myapp", "k8-1.0.0-2", "dev"); err != nil {
+		t.Fatalf("DeployDryRun: %s", err)
+	}
+	if _, err := cl.Guide(); err != nil {
+		t.Fatalf("Guide after a dry run: %s", err)
+	}
+	if gets != 1 {
+		t.Errorf("server asked %d times, want once: a dry run changes nothing", gets)
+	}
+	if _, err := cl.Deploy("myapp", "k8-1.0.0-2", "dev", ""); err != nil {
+		t.Fatalf("Deploy: %s", err)
+	}
+	if _, err := cl.Guide(); err != nil {
+		t.Fatalf("Guide after a deploy: %s", err)
+	}
+	if gets != 2 {
+		t.Errorf("server asked %d times, want twice: a deploy clears the cache", gets)
+	}
+}
@@ -6,12 +6,20 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 // Client represents an instance of a connection to the server.
 type Client struct {
-	Token string `json:"bearerToken"` // The API authorization token to the server.
-	Url   string `json:"URL"`         // The URL to the server endpoint.
+	Token     string     `json:"bearerToken"` // The API authorization token to the server.
+	Url       string     `json:"URL"`         // The URL to the server endpoint.
+	Discovery *Discovery `json:"-"`           // The capabilities of the server if known (optional).
+	Trace     *Trace     `json:"-"`           // Logs the HTTP exchanges (optional).
+	Tracer    *Tracer    `json:"-"`           // Records a span per request (optional).
+	Span      *Span      `json:"-"`           // The parent of the spans of the requests, ex: the command (optional).
+	Cache     *Cache     `json:"-"`           // Stores the responses on disk (optional).
 }
 
 type DeployRequest struct {
@@ -22,11 +30,13 @@ type DeployRequest struct {
 }
 
 type RestartRequest struct {
-	Namespace string `json:"namespace"` // The namespace where the deployment is running.
+	Memo      string `json:"memo,omitempty"` // Optional text to display in slack etc.
+	Namespace string `json:"namespace"`      // The namespace where the deployment is running.
 }
 
 type RollbackRequest struct {
-	Revision string `json:"revision"` // The revision to roll back to (optional)
+	Memo     string `json:"memo,omitempty"` // Optional text to display in slack etc.
+	Revision string `json:"revision"`       // The revision to roll back to (optional)
 }
 
 // New is a factory function that returns a new client instance.
@@ -37,6 +47,14 @@ func NewClient(url string, apiToken string) *Client {
 	}
 }
 
+// polling returns a copy of the client that revalidates cached responses on every request, for
+// the loops waiting on a change.
+func (c *Client) polling() *Client {
+	cc := *c
+	cc.Cache = c.Cache.revalidating()
+	return &cc
+}
+
 // Version prints the version of the client.
 func (c *Client) Version() string {
 	return fmt.Sprintf("%s version %s\n", applicationName, version)
@@ -46,11 +64,21 @@ func (c *Client) Version() string {
 
 // Delete removes a deployed release from the cluster.
 func (c *Client) Delete(release string) (*Response, error) {
+	return c.DeleteWithMemo(release, "")
+}
+
+// DeleteWithMemo removes a deployed release from the cluster, recording a memo with the request.
+func (c *Client) DeleteWithMemo(release string, memo string) (*Response, error) {
 	// Send the request.
 	req, err := http.NewRequest(httpDelete, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteRelease, release)), nil)
 	if err != nil {
 		return nil, err
 	}
+	if memo != "" {
+		q := req.URL.Query()
+		q.Add("m", memo)
+		req.URL.RawQuery = q.Encode()
+	}
 	return c.sendRequest(req, "releases", httpRouteReleasesVersion)
 }
 
@@ -104,8 +132,14 @@ func (c *Client) List(namespace string, format string) (*Response, error) {
 
 // Rollback removes a deployed release froms the cluster and restarts the previous one in history.
 func (c *Client) Rollback(release string, revision string) (*Response, error) {
+	return c.RollbackWithMemo(release, revision, "")
+}
+
+// RollbackWithMemo rolls back a release like Rollback, recording a memo with the request.
+func (c *Client) RollbackWithMemo(release string, revision string, memo string) (*Response, error) {
 	// Create the payload.
 	dr := &RollbackRequest{
+		Memo:     memo,
 		Revision: revision,
 	}
 	payload, err := json.Marshal(dr)
@@ -134,200 +168,19 @@ func (c *Client) Status(release string, format string) (*Response, error) {
 	return c.sendRequest(req, "releases", httpRouteReleasesVersion)
 }
 
-// Kube related commands
-
-// Configmap prints out the details of a configmap.
-func (c *Client) Configmap(name string, namespace string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteConfigmap, name)), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "configmaps", httpRouteConfigmapsVersion)
-}
-
-// Configmaps prints out a list of configmaps.
-func (c *Client) Configmaps(namespace string, format string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteConfigmaps), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	q.Add("f", format)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "configmaps", httpRouteConfigmapsVersion)
-}
-
-// Cronjob prints out the details of a running cronjob.
-func (c *Client) Cronjob(name string, namespace string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteCronjob, name)), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "cronjobs", httpRouteCronjobsVersion)
-}
-
-// Cronjobs prints out a list of cronjobs.
-func (c *Client) Cronjobs(namespace string, format string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteCronjobs), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	q.Add("f", format)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "cronjobs", httpRouteCronjobsVersion)
-}
-
-// Deployment prints out the details of a deployment.
-func (c *Client) Deployment(name string, namespace string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteDeployment, name)), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "deployments", httpRouteDeploymentsVersion)
-}
+// Kube related commands. Resources without extra parameters are served by the generic
+// ResourceList, ResourceDescribe and ResourceRestart calls in resources.go.
 
-// Deployments prints out a list of deployments.
-func (c *Client) Deployments(namespace string, format string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteDeployments), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
+// Secret prints out the details of a secret. Values are redacted by the server unless
+// reveal names a key and the server permits it to be shown.
+func (c *Client) Secret(name string, namespace string, reveal string) (*Response, error) {
+	k, _ := LookupResource("secrets")
+	q := url.Values{}
 	q.Add("n", namespace)
-	q.Add("f", format)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "deployments", httpRouteDeploymentsVersion)
-}
-
-// DeploymentRestart restarts all pods in a deployment
-func (c *Client) DeploymentRestart(name string, namespace string) (*Response, error) {
-	// Create the payload.
-	dr := &RestartRequest{
-		Namespace: namespace,
-	}
-	payload, err := json.Marshal(dr)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(httpPatch, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteDeploymentRestart, name)),
-		bytes.NewBuffer([]byte(payload)))
-	if err != nil {
-		return nil, err
+	if reveal != "" {
+		q.Add("reveal", reveal)
 	}
-	return c.sendRequest(req, "deployments", httpRouteDeploymentsVersion)
-}
-
-// Ingress prints out the details of an ingress.
-func (c *Client) Ingress(name string, namespace string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteIngress, name)), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "ingresses", httpRouteIngressesVersion)
-}
-
-// Ingresses prints out a list of ingresses.
-func (c *Client) Ingresses(namespace string, format string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteIngresses), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	q.Add("f", format)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "ingresses", httpRouteIngressesVersion)
-}
-
-// Job prints out the details of a running job.
-func (c *Client) Job(name string, namespace string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteJob, name)), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "jobs", httpRouteJobsVersion)
-}
-
-// Jobs prints out a list of jobs.
-func (c *Client) Jobs(namespace string, format string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteJobs), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	q.Add("f", format)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "jobs", httpRouteJobsVersion)
-}
-
-// Pod prints out the details of a running pod.
-func (c *Client) Pod(name string, namespace string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRoutePod, name)), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "pods", httpRoutePodsVersion)
-}
-
-// Pods prints out a list of pods.
-func (c *Client) Pods(namespace string, format string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRoutePods), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	q.Add("f", format)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "pods", httpRoutePodsVersion)
-}
-
-// Service prints out details of a service.
-func (c *Client) Service(name string, namespace string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteService, name)), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "services", httpRouteServicesVersion)
-}
-
-// Services prints out a list of services.
-func (c *Client) Services(namespace string, format string) (*Response, error) {
-	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteServices), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
-	q.Add("n", namespace)
-	q.Add("f", format)
-	req.URL.RawQuery = q.Encode()
-	return c.sendRequest(req, "services", httpRouteServicesVersion)
+	return c.resourceRequest(k, httpGet, fmt.Sprintf(k.DescribeRoute, name), q, nil)
 }
 
 // Guide retrieves the user guide from the server.
@@ -344,30 +197,109 @@ func (c *Client) Guide() (*Response, error) {
 
 // Response back from the server.
 type Response struct {
-	Status  string `json:"status"`  // Status response from the server.
-	Message string `json:"message"` // Message and or data back from the server.
+	Status    string `json:"status"`  // Status response from the server.
+	Message   string `json:"message"` // Message and or data back from the server.
+	RequestID string `json:"-"`       // The X-Request-ID sent with the request.
+}
+
+// Decode unmarshals a JSON formatted message from the server into v.
+func (r *Response) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(r.Message), v)
 }
 
 // sendRequest adds some metadata, sends the request to the server, and returns the response.
 func (c *Client) sendRequest(req *http.Request, resource string, apiVersion string) (*Response, error) {
+	if err := c.negotiate(resource, apiVersion); err != nil {
+		return nil, err
+	}
 	req.Header.Add("Accept", fmt.Sprintf("application/vnd.%s.%s-%s+json", serverName, resource, apiVersion))
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.Token))
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-Request-ID", createV4UUID()) // For logging/sync purposes.
+	requestID := createV4UUID()
+	req.Header.Add("X-Request-ID", requestID) // For logging/sync purposes.
+
+	cacheable := c.Cache.cacheable(req, resource)
+	var cachePath string
+	var cached *cacheEntry
+	if cacheable {
+		cachePath = c.Cache.path(resource, apiVersion, req)
+		if cached = c.Cache.load(cachePath); cached != nil {
+			if c.Cache.fresh(resource, cached) {
+				var result Response
+				if err := json.Unmarshal(cached.Body, &result); err == nil {
+					c.Trace.cached(req, time.Since(cached.Stored))
+					result.RequestID = requestID
+					return &result, nil
+				}
+			}
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+		}
+	}
+
+	span := c.Tracer.Start(c.Span, fmt.Sprintf("%s %s", req.Method, resource), SpanKindClient)
+	if span != nil {
+		req.Header.Set("traceparent", span.TraceParent())
+		span.SetAttribute("http.request.method", req.Method)
+		span.SetAttribute("url.full", req.URL.String())
+		span.SetAttribute("server.address", req.URL.Hostname())
+		span.SetAttribute("k8ctl.request_id", requestID)
+	}
+	var spanErr error
+	defer func() { span.Finish(spanErr) }()
+
+	x := &exchange{resource: resource, req: req, start: time.Now()}
+	if c.Trace != nil && req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			x.reqBody, _ = ioutil.ReadAll(rc)
+		}
+	}
+	defer func() {
+		x.total = time.Since(x.start)
+		c.Trace.record(x)
+	}()
 
 	cl := &http.Client{}
 	resp, err := cl.Do(req)
 	if err != nil {
+		x.err, spanErr = err, err
 		return nil, err
 	}
+	x.resp, x.wait = resp, time.Since(x.start)
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= http.StatusBadRequest {
+		spanErr = fmt.Errorf("%s", resp.Status)
+	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		x.err, spanErr = err, err
 		return nil, err
 	}
+	x.respBody = body
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		cached.Stored = time.Now()
+		c.Cache.store(cachePath, resource, cached)
+		body = cached.Body
+	}
 	var result Response
 	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body)), RequestID: requestID}
+		}
 		return nil, err
 	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: result.Message, RequestID: requestID}
+	}
+	result.RequestID = requestID
+	switch {
+	case cacheable && resp.StatusCode == http.StatusOK:
+		c.Cache.store(cachePath, resource, &cacheEntry{URL: req.URL.String(), ETag: resp.Header.Get("ETag"),
+			Stored: time.Now(), Body: body})
+	case c.Cache != nil && req.Method != http.MethodGet:
+		c.Cache.invalidateAfter(resource, req.URL.Path)
+	}
 	return &result, nil
 }
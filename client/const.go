@@ -1,46 +1,89 @@
 package client
 
+import "time"
+
 const (
 	applicationName = "k8ctl"        // Application name.
 	serverName      = "k8ctl-server" // Server name.
 	version         = "1.0.4"        // Application version.
 
 	// Helm related
-	httpRouteReleases        = "/releases"             // List, deploy releases.(?e=environment; POST)
-	httpRouteRelease         = "/releases/%s"          // Get, or Delete a release. (GET=status; DELETE=delete)
-	httpRouteReleaseRollback = "/releases/%s/rollback" // Rollback a release. (PUT=rollback)
-	httpRouteReleaseHistory  = "/releases/%s/history"  // Display the history of a release.
+	httpRouteReleases              = "/releases"                    // List, deploy releases.(?e=environment; POST)
+	httpRouteRelease               = "/releases/%s"                 // Get, or Delete a release. (GET=status; DELETE=delete)
+	httpRouteReleaseDryRun         = "/releases/dry-run"            // Render a deploy without applying it. (POST)
+	httpRouteReleaseRollback       = "/releases/%s/rollback"        // Rollback a release. (PUT=rollback)
+	httpRouteReleaseHistory        = "/releases/%s/history"         // Display the history of a release.
+	httpRouteReleaseManifest       = "/releases/%s/manifest"        // Display the rendered manifests of a revision. (?r=revision)
+	httpRouteReleaseNotes          = "/releases/%s/notes"           // Display the chart notes of a revision. (?r=revision)
+	httpRouteReleaseValues         = "/releases/%s/values"          // Display the values of a revision. (?r=revision)
+	httpRouteReleaseRollout        = "/releases/%s/rollout"         // Canary or blue/green rollout. (POST=start; PATCH=weight; GET=status; DELETE=abort)
+	httpRouteReleaseRolloutPromote = "/releases/%s/rollout/promote" // Make the new version of a rollout stable. (PUT)
 
 	// Kube related
-	httpRouteConfigmaps        = "/configmaps"             // Display a list of configmaps.
-	httpRouteConfigmap         = "/configmaps/%s"          // Display details of a configmap.
-	httpRouteCronjobs          = "/cronjobs"               // Display a list of cronjobs.
-	httpRouteCronjob           = "/cronjobs/%s"            // Display details of a cronjob.
-	httpRouteDeployments       = "/deployments"            // Display a list of deployments.
-	httpRouteDeployment        = "/deployments/%s"         // Display details of a deployment.
-	httpRouteDeploymentRestart = "/deployments/%s/restart" // Restart a deployment and its pods (PATCH)
-	httpRouteIngresses         = "/ingresses"              // Display a list of ingresses.
-	httpRouteIngress           = "/ingresses/%s"           // Display details of an ingress.
-	httpRouteJobs              = "/jobs"                   // Display a list of jobs.
-	httpRouteJob               = "/jobs/%s"                // Display details of a jobs.
-	httpRoutePods              = "/pods"                   // Display a list of running pods.
-	httpRoutePod               = "/pods/%s"                // Display details of a running pod.
-	httpRouteServices          = "/services"               // Display a list of running services.
-	httpRouteService           = "/services/%s"            // Display details of a running service.
+	httpRouteConfigmaps         = "/configmaps"              // Display a list of configmaps.
+	httpRouteConfigmap          = "/configmaps/%s"           // Display details of a configmap.
+	httpRouteCronjobs           = "/cronjobs"                // Display a list of cronjobs.
+	httpRouteCronjob            = "/cronjobs/%s"             // Display details of a cronjob.
+	httpRouteDaemonsets         = "/daemonsets"              // Display a list of daemonsets.
+	httpRouteDaemonset          = "/daemonsets/%s"           // Display details of a daemonset.
+	httpRouteDaemonsetRestart   = "/daemonsets/%s/restart"   // Restart a daemonset and its pods (PATCH)
+	httpRouteDeployments        = "/deployments"             // Display a list of deployments.
+	httpRouteDeployment         = "/deployments/%s"          // Display details of a deployment.
+	httpRouteDeploymentRestart  = "/deployments/%s/restart"  // Restart a deployment and its pods (PATCH)
+	httpRouteHpas               = "/hpas"                    // Display a list of horizontal pod autoscalers.
+	httpRouteHpa                = "/hpas/%s"                 // Display details of a horizontal pod autoscaler.
+	httpRouteIngresses          = "/ingresses"               // Display a list of ingresses.
+	httpRouteIngress            = "/ingresses/%s"            // Display details of an ingress.
+	httpRouteJobs               = "/jobs"                    // Display a list of jobs.
+	httpRouteJob                = "/jobs/%s"                 // Display details of a jobs.
+	httpRouteMetricsPods        = "/metrics/pods"            // Display resource usage of pods in a namespace.
+	httpRouteMetricsNamespace   = "/metrics/namespaces/%s"   // Display resource usage totals for a namespace.
+	httpRoutePdbs               = "/pdbs"                    // Display a list of pod disruption budgets.
+	httpRoutePdb                = "/pdbs/%s"                 // Display details of a pod disruption budget.
+	httpRoutePods               = "/pods"                    // Display a list of running pods.
+	httpRoutePod                = "/pods/%s"                 // Display details of a running pod.
+	httpRouteSecrets            = "/secrets"                 // Display a list of secrets (metadata only).
+	httpRouteSecret             = "/secrets/%s"              // Display details of a secret (values redacted).
+	httpRouteServices           = "/services"                // Display a list of running services.
+	httpRouteService            = "/services/%s"             // Display details of a running service.
+	httpRouteStatefulsets       = "/statefulsets"            // Display a list of statefulsets.
+	httpRouteStatefulset        = "/statefulsets/%s"         // Display details of a statefulset.
+	httpRouteStatefulsetRestart = "/statefulsets/%s/restart" // Restart a statefulset and its pods (PATCH)
 
 	// Other
-	httpRouteGuide = "/guide" // Get information on how to use this application from the server.
+	httpRouteApprovals       = "/approvals"            // List, or request approval of a deploy. (GET=list; POST=request)
+	httpRouteApproval        = "/approvals/%s"         // Display an approval request.
+	httpRouteApprovalApprove = "/approvals/%s/approve" // Approve a deploy; the server applies it. (PUT)
+	httpRouteApprovalReject  = "/approvals/%s/reject"  // Reject a deploy. (PUT)
+	httpRouteGuide           = "/guide"                // Get information on how to use this application from the server.
+	httpRouteDiscovery       = "/api-resources"        // Get the server version and the resources, verbs and API versions it supports.
+	httpRouteFreezes         = "/freezes"              // List, or set freezes of namespaces. (GET=list; POST=set)
+	httpRouteFreeze          = "/freezes/%s"           // Clear the freeze of a namespace. (DELETE=clear)
 
 	// API Versions
-	httpRouteReleasesVersion    = "v1.0.0"
-	httpRouteConfigmapsVersion  = "v1.0.0"
-	httpRouteCronjobsVersion    = "v1.0.0"
-	httpRouteDeploymentsVersion = "v1.0.0"
-	httpRouteIngressesVersion   = "v1.0.0"
-	httpRouteJobsVersion        = "v1.0.0"
-	httpRoutePodsVersion        = "v1.0.0"
-	httpRouteServicesVersion    = "v1.0.0"
-	httpRouteGuideVersion       = "v1.0.0"
+	httpRouteReleasesVersion     = "v1.0.0"
+	httpRouteApprovalsVersion    = "v1.0.0"
+	httpRouteRolloutsVersion     = "v1.0.0"
+	httpRouteConfigmapsVersion   = "v1.0.0"
+	httpRouteCronjobsVersion     = "v1.0.0"
+	httpRouteDaemonsetsVersion   = "v1.0.0"
+	httpRouteDeploymentsVersion  = "v1.0.0"
+	httpRouteHpasVersion         = "v1.0.0"
+	httpRouteIngressesVersion    = "v1.0.0"
+	httpRouteJobsVersion         = "v1.0.0"
+	httpRouteMetricsVersion      = "v1.0.0"
+	httpRoutePdbsVersion         = "v1.0.0"
+	httpRoutePodsVersion         = "v1.0.0"
+	httpRouteSecretsVersion      = "v1.0.0"
+	httpRouteServicesVersion     = "v1.0.0"
+	httpRouteStatefulsetsVersion = "v1.0.0"
+	httpRouteGuideVersion        = "v1.0.0"
+	httpRouteDiscoveryVersion    = "v1.0.0"
+	httpRouteFreezesVersion      = "v1.0.0"
+
+	releaseWaitInterval  = 5 * time.Second  // How often a release is polled while waiting for it to deploy.
+	approvalWaitInterval = 10 * time.Second // How often an approval request is polled while waiting for a review.
+	notifyTimeout        = 10 * time.Second // How long to wait for a notification sink to answer.
 
 	httpGet    = "GET"
 	httpPatch  = "PATCH"
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@hourly",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2026-10-19 is a Monday.
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{"* * * * *", at(19, 3, 7), true},
+		{"*/15 * * * *", at(19, 3, 45), true},
+		{"*/15 * * * *", at(19, 3, 50), false},
+		{"5/20 * * * *", at(19, 3, 45), true},
+		{"0 9-17 * * 1-5", at(19, 9, 0), true},
+		{"0 9-17 * * 1-5", at(18, 9, 0), false},
+		{"0 0 * * 7", at(18, 0, 0), true},
+		{"0 0 * * 0", at(18, 0, 0), true},
+		{"30 2 1,15 * *", at(15, 2, 30), true},
+		{"30 2 1,15 * *", at(16, 2, 30), false},
+		// Both days restricted: either matches.
+		{"0 0 1 * 1", at(19, 0, 0), true},
+		{"0 0 1 * 1", at(20, 0, 0), false},
+		{"0 0 * 11 *", at(19, 0, 0), false},
+	}
+	for _, tt := range tests {
+		s, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %s", tt.expr, err)
+		}
+		if got := s.Matches(tt.t); got != tt.want {
+			t.Errorf("%q matches %s = %t, want %t", tt.expr, tt.t.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func TestCronLastFire(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 34, 56, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		lookback time.Duration
+		want     time.Time
+		ok       bool
+	}{
+		{"0 * * * *", time.Hour, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), true},
+		{"34 12 * * *", time.Hour, time.Date(2026, 10, 19, 12, 34, 0, 0, time.UTC), true},
+		{"0 0 * * 5", 7 * 24 * time.Hour, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 * * 5", 24 * time.Hour, time.Time{}, false},
+		{"0 0 29 2 *", cronLookback, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		s, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %s", tt.expr, err)
+		}
+		got, ok := s.LastFire(now, tt.lookback)
+		if !got.Equal(tt.want) || ok != tt.ok {
+			t.Errorf("%q LastFire = %s, %t, want %s, %t", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+// The calls of each kind of resource, kept for the programs built on the client. The commands
+// use the generic ResourceList, ResourceDescribe and ResourceRestart calls of resources.go.
+
+// Configmap prints out the details of a configmap.
+//
+// Deprecated: use ResourceDescribe("configmaps", name, namespace).
+func (c *Client) Configmap(name string, namespace string) (*Response, error) {
+	return c.ResourceDescribe("configmaps", name, namespace)
+}
+
+// Configmaps prints out a list of configmaps.
+//
+// Deprecated: use ResourceList("configmaps", namespace, format).
+func (c *Client) Configmaps(namespace string, format string) (*Response, error) {
+	return c.ResourceList("configmaps", namespace, format)
+}
+
+// Cronjob prints out the details of a running cronjob.
+//
+// Deprecated: use ResourceDescribe("cronjobs", name, namespace).
+func (c *Client) Cronjob(name string, namespace string) (*Response, error) {
+	return c.ResourceDescribe("cronjobs", name, namespace)
+}
+
+// Cronjobs prints out a list of cronjobs.
+//
+// Deprecated: use ResourceList("cronjobs", namespace, format).
+func (c *Client) Cronjobs(namespace string, format string) (*Response, error) {
+	return c.ResourceList("cronjobs", namespace, format)
+}
+
+// Deployment prints out the details of a deployment.
+//
+// Deprecated: use ResourceDescribe("deployments", name, namespace).
+func (c *Client) Deployment(name string, namespace string) (*Response, error) {
+	return c.ResourceDescribe("deployments", name, namespace)
+}
+
+// Deployments prints out a list of deployments.
+//
+// Deprecated: use ResourceList("deployments", namespace, format).
+func (c *Client) Deployments(namespace string, format string) (*Response, error) {
+	return c.ResourceList("deployments", namespace, format)
+}
+
+// DeploymentRestart restarts all pods in a deployment.
+//
+// Deprecated: use ResourceRestart("deployments", name, namespace, memo).
+func (c *Client) DeploymentRestart(name string, namespace string) (*Response, error) {
+	return c.ResourceRestart("deployments", name, namespace, "")
+}
+
+// Ingress prints out the details of an ingress.
+//
+// Deprecated: use ResourceDescribe("ingresses", name, namespace).
+func (c *Client) Ingress(name string, namespace string) (*Response, error) {
+	return c.ResourceDescribe("ingresses", name, namespace)
+}
+
+// Ingresses prints out a list of ingresses.
+//
+// Deprecated: use ResourceList("ingresses", namespace, format).
+func (c *Client) Ingresses(namespace string, format string) (*Response, error) {
+	return c.ResourceList("ingresses", namespace, format)
+}
+
+// Job prints out the details of a running job.
+//
+// Deprecated: use ResourceDescribe("jobs", name, namespace).
+func (c *Client) Job(name string, namespace string) (*Response, error) {
+	return c.ResourceDescribe("jobs", name, namespace)
+}
+
+// Jobs prints out a list of jobs.
+//
+// Deprecated: use ResourceList("jobs", namespace, format).
+func (c *Client) Jobs(namespace string, format string) (*Response, error) {
+	return c.ResourceList("jobs", namespace, format)
+}
+
+// Pod prints out the details of a running pod.
+//
+// Deprecated: use ResourceDescribe("pods", name, namespace).
+func (c *Client) Pod(name string, namespace string) (*Response, error) {
+	return c.ResourceDescribe("pods", name, namespace)
+}
+
+// Pods prints out a list of pods.
+//
+// Deprecated: use ResourceList("pods", namespace, format).
+func (c *Client) Pods(namespace string, format string) (*Response, error) {
+	return c.ResourceList("pods", namespace, format)
+}
+
+// Service prints out details of a service.
+//
+// Deprecated: use ResourceDescribe("services", name, namespace).
+func (c *Client) Service(name string, namespace string) (*Response, error) {
+	return c.ResourceDescribe("services", name, namespace)
+}
+
+// Services prints out a list of services.
+//
+// Deprecated: use ResourceList("services", namespace, format).
+func (c *Client) Services(namespace string, format string) (*Response, error) {
+	return c.ResourceList("services", namespace, format)
+}
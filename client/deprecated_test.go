@@ -0,0 +1,36 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeprecatedRoutes(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Method + " " + r.URL.Path
+		fmt.Fprint(w, `{"status":"OK","message":"done"}`)
+	}))
+	defer srv.Close()
+	cl := NewClient(srv.URL, "token")
+
+	tests := []struct {
+		call func() (*Response, error)
+		want string
+	}{
+		{func() (*Response, error) { return cl.Pods("dev", "") }, "GET /pods"},
+		{func() (*Response, error) { return cl.Pod("web-1", "dev") }, "GET /pods/web-1"},
+		{func() (*Response, error) { return cl.Services("dev", "") }, "GET /services"},
+		{func() (*Response, error) { return cl.DeploymentRestart("web", "dev") }, "PATCH /deployments/web/restart"},
+	}
+	for _, tt := range tests {
+		if _, err := tt.call(); err != nil {
+			t.Errorf("%s: %s", tt.want, err)
+		}
+		if got != tt.want {
+			t.Errorf("called %s, want %s", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+// diffOp is an edit of a line: ' ' kept, '-' removed or '+' added.
+type diffOp struct {
+	Kind byte
+	Text string
+	A, B int // Line numbers in a and b, from 0.
+}
+
+// UnifiedDiff returns the unified diff of two texts, or an empty string if they are equal.
+func UnifiedDiff(a string, b string, fromName string, toName string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(ops); {
+		// Find the next change.
+		for i < len(ops) && ops[i].Kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		// Extend the hunk while changes are within twice the context of each other.
+		end := i
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+		writeHunk(&out, ops[start:end])
+		i = end
+	}
+	return out.String()
+}
+
+// writeHunk writes the header and lines of a hunk.
+func writeHunk(out *strings.Builder, ops []diffOp) {
+	aStart, bStart, aLen, bLen := -1, -1, 0, 0
+	for _, op := range ops {
+		if op.Kind != '+' {
+			if aStart < 0 {
+				aStart = op.A
+			}
+			aLen++
+		}
+		if op.Kind != '-' {
+			if bStart < 0 {
+				bStart = op.B
+			}
+			bLen++
+		}
+	}
+	if aStart < 0 {
+		aStart = ops[0].A - 1
+	}
+	if bStart < 0 {
+		bStart = ops[0].B - 1
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart+1, aLen, bStart+1, bLen)
+	for _, op := range ops {
+		fmt.Fprintf(out, "%c%s\n", op.Kind, op.Text)
+	}
+}
+
+// splitLines splits a text into lines without their line endings.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the edits turning a into b using the linear space variant of the Myers
+// algorithm: it finds the middle snake of an optimal path and recurses on both sides of it,
+// so memory is O(N+M) rather than O(D·(N+M)) for very different texts.
+func diffLines(a []string, b []string) []diffOp {
+	d := &differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.ops
+}
+
+// differ accumulates the edits of diffLines.
+type differ struct {
+	a, b []string
+	ops  []diffOp
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo int, aHi int, bLo int, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{Kind: ' ', Text: d.a[aLo], A: aLo, B: bLo})
+		aLo++
+		bLo++
+	}
+	aEnd := aHi
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.ops = append(d.ops, diffOp{Kind: '+', Text: d.b[y], A: aLo, B: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.ops = append(d.ops, diffOp{Kind: '-', Text: d.a[x], A: x, B: bLo})
+		}
+	default:
+		// Both sides differ at their ends, so the snake splits the edits in two smaller sets.
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.ops = append(d.ops, diffOp{Kind: ' ', Text: d.a[x], A: x, B: y})
+		}
+		d.compare(u, aHi, v, bHi)
+	}
+	for ; aHi < aEnd; aHi, bHi = aHi+1, bHi+1 {
+		d.ops = append(d.ops, diffOp{Kind: ' ', Text: d.a[aHi], A: aHi, B: bHi})
+	}
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of the middle snake of an optimal path
+// from (aLo, bLo) to (aHi, bHi), searching forward from the start and backward from the end
+// until the paths overlap.
+func (d *differ) middleSnake(aLo int, aHi int, bLo int, bHi int) (int, int, int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// vf[offset+k] is the furthest x on diagonal k = x-y going forward; vb[offset+k] the furthest
+	// distance from the end on diagonal k of the reversed texts.
+	vf := make([]int, 2*max+2)
+	vb := make([]int, 2*max+2)
+	for e := 0; e <= max; e++ {
+		for k := -e; k <= e; k += 2 {
+			var x int
+			if k == -e || (k != e && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			if r := delta - k; odd && r >= -(e-1) && r <= e-1 && x+vb[offset+r] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+		for k := -e; k <= e; k += 2 {
+			var x int
+			if k == -e || (k != e && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+			if f := delta - k; !odd && f >= -e && f <= e && x+vf[offset+f] >= n {
+				return aHi - x, bHi - y, aHi - x0, bHi - y0
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
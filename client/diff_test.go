@@ -0,0 +1,142 @@
+package client
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDiffLinesEmpty(t *testing.T) {
+	if ops := diffLines(nil, nil); len(ops) != 0 {
+		t.Errorf("diffLines(nil, nil) = %v, want no edits", ops)
+	}
+	if ops := diffLines(nil, []string{"a"}); len(ops) != 1 || ops[0].Kind != '+' {
+		t.Errorf("diffLines(nil, [a]) = %v, want one addition", ops)
+	}
+	if ops := diffLines([]string{"a"}, nil); len(ops) != 1 || ops[0].Kind != '-' {
+		t.Errorf("diffLines([a], nil) = %v, want one removal", ops)
+	}
+}
+
+func TestDiffLinesRoundTrip(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"a b c", "a b c"},
+		{"a b c", "a x c"},
+		{"a b c a b b a", "c b a b a c"},
+		{"a", "b c d"},
+		{"x y z", ""},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		var gotA, gotB []string
+		for _, op := range diffLines(a, b) {
+			if op.Kind != '+' {
+				gotA = append(gotA, op.Text)
+			}
+			if op.Kind != '-' {
+				gotB = append(gotB, op.Text)
+			}
+		}
+		if strings.Join(gotA, " ") != tt.a || strings.Join(gotB, " ") != tt.b {
+			t.Errorf("diffLines(%q, %q) rebuilds %q and %q", tt.a, tt.b, gotA, gotB)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := UnifiedDiff("", "", "a", "b"); got != "" {
+		t.Errorf("UnifiedDiff of empty texts = %q, want empty", got)
+	}
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n"
+	want := `--- a
++++ b
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`
+	if got := UnifiedDiff(a, b, "a", "b"); got != want {
+		t.Errorf("UnifiedDiff = %q, want %q", got, want)
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a []string, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	text := func() []string {
+		lines := make([]string, r.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(3)))
+		}
+		return lines
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := text(), text()
+		ops := diffLines(a, b)
+		edits, x, y := 0, 0, 0
+		for _, op := range ops {
+			if op.A != x || op.B != y {
+				t.Fatalf("diffLines(%q, %q): op %+v at a %d, b %d", a, b, op, x, y)
+			}
+			switch op.Kind {
+			case ' ':
+				x, y = x+1, y+1
+			case '-':
+				edits, x = edits+1, x+1
+			case '+':
+				edits, y = edits+1, y+1
+			}
+		}
+		if x != len(a) || y != len(b) {
+			t.Fatalf("diffLines(%q, %q) ends at a %d, b %d", a, b, x, y)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("diffLines(%q, %q) makes %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	a, b := make([]string, 3000), make([]string, 3000)
+	for i := range a {
+		a[i] = fmt.Sprintf("a%d", i)
+		b[i] = fmt.Sprintf("b%d", i)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := diffLines(a, b)
+	runtime.ReadMemStats(&after)
+	if len(ops) != 6000 {
+		t.Errorf("diffLines of different texts made %d edits, want 6000", len(ops))
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 32<<20 {
+		t.Errorf("diffLines of 3000 lines allocated %d MB", alloc>>20)
+	}
+}
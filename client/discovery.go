@@ -0,0 +1,201 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// APIResource is a resource the server supports along with its verbs and API versions.
+type APIResource struct {
+	Name     string   `json:"name"`     // The resource name, ex: pods.
+	Verbs    []string `json:"verbs"`    // Operations supported, ex: list, describe.
+	Versions []string `json:"versions"` // API versions served, ex: v1.0.0.
+}
+
+// Discovery is the capability document returned by the server.
+type Discovery struct {
+	ServerVersion string        `json:"version"`   // The version of k8ctl-server.
+	Resources     []APIResource `json:"resources"` // Resources the server supports.
+	FetchedAt     time.Time     `json:"fetchedAt"` // When the document was retrieved (client side).
+
+	// Namespaces whose deploys the server applies only once approved by a second person.
+	ApprovalNamespaces []string `json:"approvalNamespaces,omitempty"`
+
+	Unavailable bool `json:"unavailable,omitempty"` // The server has no discovery route (client side).
+}
+
+// Resource returns the named resource if the server supports it.
+func (d *Discovery) Resource(name string) (*APIResource, bool) {
+	for i := range d.Resources {
+		if d.Resources[i].Name == name {
+			return &d.Resources[i], true
+		}
+	}
+	return nil, false
+}
+
+// Supports returns true if the server supports the verb on the resource. An empty verb
+// checks the resource only. Resources that list no verbs support them all.
+func (d *Discovery) Supports(resource string, verb string) bool {
+	r, ok := d.Resource(resource)
+	if !ok {
+		return false
+	}
+	if verb == "" || len(r.Verbs) == 0 {
+		return true
+	}
+	for _, v := range r.Verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresApproval returns true if the server applies deploys to the namespace only once
+// approved. A nil document, ex: of a server without a discovery route, requires none.
+func (d *Discovery) RequiresApproval(namespace string) bool {
+	if d == nil {
+		return false
+	}
+	for _, ns := range d.ApprovalNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsVersion returns true if the server serves the API version of the resource.
+// Resources that list no versions are assumed to serve the client's.
+func (d *Discovery) SupportsVersion(resource string, apiVersion string) bool {
+	r, ok := d.Resource(resource)
+	if !ok {
+		return false
+	}
+	if len(r.Versions) == 0 {
+		return true
+	}
+	for _, v := range r.Versions {
+		if v == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionSkew returns a warning if the major or minor version of the server differs from the
+// client, otherwise an empty string.
+func (d *Discovery) VersionSkew() string {
+	if d.ServerVersion == "" || majorMinor(d.ServerVersion) == majorMinor(version) {
+		return ""
+	}
+	return fmt.Sprintf("%s version %s may not be compatible with %s version %s",
+		applicationName, version, serverName, d.ServerVersion)
+}
+
+// Fresh returns true if the document was retrieved within ttl.
+func (d *Discovery) Fresh(ttl time.Duration) bool {
+	return time.Since(d.FetchedAt) < ttl
+}
+
+// Save writes the document to a cache file.
+func (d *Discovery) Save(path string) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// LoadDiscovery reads a document previously saved to a cache file.
+func LoadDiscovery(path string) (*Discovery, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var d Discovery
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// APIVersions returns the API version the client speaks for each resource.
+func APIVersions() map[string]string {
+	v := map[string]string{
+		"releases":  httpRouteReleasesVersion,
+		"metrics":   httpRouteMetricsVersion,
+		"guide":     httpRouteGuideVersion,
+		"discovery": httpRouteDiscoveryVersion,
+	}
+	for _, k := range Resources() {
+		v[k.Name] = k.APIVersion
+	}
+	return v
+}
+
+// ServerVersion returns the version of the client and the server.
+func (c *Client) ServerVersion() (string, error) {
+	d, err := c.Discover()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s version %s\n%s version %s\n", applicationName, version, serverName, d.ServerVersion), nil
+}
+
+// Discover retrieves the server version and the resources, verbs and API versions it supports.
+func (c *Client) Discover() (*Discovery, error) {
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteDiscovery), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	resp, err := c.sendRequest(req, "discovery", httpRouteDiscoveryVersion)
+	if err != nil {
+		return nil, err
+	}
+	var d Discovery
+	if err := resp.Decode(&d); err != nil {
+		return nil, fmt.Errorf("decode discovery: %w", err)
+	}
+	d.FetchedAt = time.Now()
+	return &d, nil
+}
+
+// negotiate checks that the server serves the API version of a resource when the capabilities
+// of the server are known.
+func (c *Client) negotiate(resource string, apiVersion string) error {
+	if c.Discovery == nil || resource == "discovery" {
+		return nil
+	}
+	if _, ok := c.Discovery.Resource(resource); !ok {
+		return fmt.Errorf("%s does not support %s", serverName, resource)
+	}
+	if !c.Discovery.SupportsVersion(resource, apiVersion) {
+		r, _ := c.Discovery.Resource(resource)
+		return fmt.Errorf("%s API %s is not supported by %s (supports %s)", resource, apiVersion, serverName,
+			strings.Join(r.Versions, ", "))
+	}
+	return nil
+}
+
+// majorMinor returns the major.minor part of a version, ex: 1.0.4 => 1.0.
+func majorMinor(v string) string {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return v
+	}
+	return parts[0] + "." + parts[1]
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrTimeout is wrapped by the errors of calls that gave up waiting, ex: for a release to deploy.
+var ErrTimeout = errors.New("timed out")
+
+// ErrNotFound is wrapped by the errors of lookups that found nothing, ex: a release missing
+// from the list of its namespace.
+var ErrNotFound = errors.New("not found")
+
+// APIError is a response of the server with an error status.
+type APIError struct {
+	StatusCode int    // The HTTP status code, ex: 404.
+	Message    string // The message of the server.
+	RequestID  string // The X-Request-ID sent with the request.
+}
+
+// Error returns the message of the server, or the status if there is none.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server answered %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return e.Message
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// releaseStatuses are the statuses of a release exposed by k8ctl_release_status, so that a
+// status can be alerted on while it is 0. Other statuses are added when seen.
+var releaseStatuses = []string{ReleaseDeployed, ReleaseFailed, "pending-install", "pending-upgrade",
+	"pending-rollback", "superseded", "uninstalling", "unknown"}
+
+// ScrapeResources are the resources read by ScrapeMetrics, in the order they are read.
+var ScrapeResources = []string{"releases", "deployments", "pods", "jobs", "cronjobs"}
+
+// ScrapeMetrics reads the releases and resources of a cluster and returns them as Prometheus
+// metrics, along with the errors of the resources that could not be read. Each resource of
+// resources, ex: ScrapeResources, is read across all namespaces if namespaces is empty.
+// A resource that cannot be read has no samples and a k8ctl_exporter_scrape_success of 0.
+func ScrapeMetrics(cl Interface, cluster string, namespaces []string, resources []string,
+	now time.Time) (*Exposition, []error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	e := NewExposition()
+	var errs []error
+	start := time.Now()
+	for _, resource := range resources {
+		var err error
+		for _, ns := range namespaces {
+			if err = scrapeResource(e, cl, cluster, ns, resource); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", resource, err))
+				break
+			}
+		}
+		e.Add("k8ctl_exporter_scrape_success", MetricGauge, "Whether the last scrape of a resource succeeded.",
+			boolValue(err == nil), "cluster", cluster, "resource", resource)
+	}
+	e.Add("k8ctl_exporter_scrape_duration_seconds", MetricGauge, "How long the last scrape of a cluster took.",
+		time.Since(start).Seconds(), "cluster", cluster)
+	e.Add("k8ctl_exporter_last_scrape_timestamp_seconds", MetricGauge, "When a cluster was last scraped.",
+		float64(now.Unix()), "cluster", cluster)
+	return e, errs
+}
+
+// scrapeResource adds the metrics of a resource of a namespace.
+func scrapeResource(e *Exposition, cl Interface, cluster string, namespace string, resource string) error {
+	if resource == "releases" {
+		releases, err := cl.Releases(namespace)
+		if err != nil {
+			return err
+		}
+		for _, rel := range releases {
+			addReleaseMetrics(e, cluster, &rel)
+		}
+		return nil
+	}
+	rows, err := listRows(cl, resource, namespace)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		labels := []string{"cluster", cluster, "namespace", rowNamespace(namespace, row), singular(resource), row["name"]}
+		switch resource {
+		case "deployments":
+			addDeploymentMetrics(e, row, labels)
+		case "pods":
+			addPodMetrics(e, row, labels)
+		case "jobs":
+			failed, err := jobFailed(cl, namespace, row)
+			if err != nil {
+				return err
+			}
+			addJobMetrics(e, row, labels, failed)
+		case "cronjobs":
+			addCronjobMetrics(e, row, labels)
+		default:
+			return fmt.Errorf("unknown resource %s", resource)
+		}
+	}
+	return nil
+}
+
+// addReleaseMetrics adds the revision and status of a release.
+func addReleaseMetrics(e *Exposition, cluster string, rel *Release) {
+	labels := []string{"cluster", cluster, "namespace", rel.Namespace, "release", rel.Name}
+	e.Add("k8ctl_release_revision", MetricGauge, "The current revision of a release.",
+		float64(rel.Revision), labels...)
+	e.Add("k8ctl_release_info", MetricGauge, "The chart and version deployed by a release, always 1.", 1,
+		append(labels, "chart", rel.Chart, "app_version", rel.AppVersion, "version_tag", rel.VersionTag)...)
+	e.Add("k8ctl_release_updated_timestamp_seconds", MetricGauge, "When the current revision was deployed.",
+		float64(rel.Updated.Unix()), labels...)
+	statuses := releaseStatuses
+	if !contains(statuses, rel.Status) {
+		statuses = append(statuses[:len(statuses):len(statuses)], rel.Status)
+	}
+	for _, status := range statuses {
+		e.Add("k8ctl_release_status", MetricGauge, "The status of a release: 1 for its current status, 0 otherwise.",
+			boolValue(status == rel.Status), append(labels, "status", status)...)
+	}
+}
+
+// addDeploymentMetrics adds the replica readiness of a deployment.
+func addDeploymentMetrics(e *Exposition, row map[string]string, labels []string) {
+	ready, desired, ok := parseRatio(row["ready"])
+	if !ok {
+		return
+	}
+	e.Add("k8ctl_deployment_replicas", MetricGauge, "The desired replicas of a deployment.",
+		float64(desired), labels...)
+	e.Add("k8ctl_deployment_replicas_ready", MetricGauge, "The ready replicas of a deployment.",
+		float64(ready), labels...)
+	if available, err := strconv.Atoi(row["available"]); err == nil {
+		e.Add("k8ctl_deployment_replicas_available", MetricGauge, "The available replicas of a deployment.",
+			float64(available), labels...)
+	}
+}
+
+// addPodMetrics adds the restarts and readiness of a pod.
+func addPodMetrics(e *Exposition, row map[string]string, labels []string) {
+	e.Add("k8ctl_pod_restarts_total", MetricCounter, "The container restarts of a pod.",
+		float64(leadingInt(row["restarts"])), labels...)
+	if ready, total, ok := parseRatio(row["ready"]); ok {
+		e.Add("k8ctl_pod_ready", MetricGauge, "Whether every container of a pod is ready.",
+			boolValue(ready == total), labels...)
+	}
+	e.Add("k8ctl_pod_status", MetricGauge, "The status of a pod shown by list, always 1.", 1,
+		append(labels, "status", row["status"])...)
+}
+
+// addJobMetrics adds the outcome of a job.
+func addJobMetrics(e *Exposition, row map[string]string, labels []string, failed bool) {
+	done, total, ok := parseRatio(row["completions"])
+	if ok {
+		e.Add("k8ctl_job_completions", MetricGauge, "The completions required by a job.", float64(total), labels...)
+		e.Add("k8ctl_job_succeeded", MetricGauge, "The completions of a job so far.", float64(done), labels...)
+	}
+	e.Add("k8ctl_job_complete", MetricGauge, "Whether a job completed.", boolValue(ok && done >= total), labels...)
+	e.Add("k8ctl_job_failed", MetricGauge, "Whether a job failed.", boolValue(failed), labels...)
+}
+
+// addCronjobMetrics adds the last run of a cronjob. Ages are rounded down by the server, ex:
+// to the hour past an hour, so the age is a lower bound. A cronjob that never ran has no
+// k8ctl_cronjob_last_schedule_age_seconds.
+func addCronjobMetrics(e *Exposition, row map[string]string, labels []string) {
+	if ago, ok := parseAge(row["last schedule"]); ok {
+		e.Add("k8ctl_cronjob_last_schedule_age_seconds", MetricGauge, "Seconds since a cronjob last ran.",
+			ago.Seconds(), labels...)
+	}
+	e.Add("k8ctl_cronjob_suspended", MetricGauge, "Whether a cronjob is suspended.",
+		boolValue(strings.EqualFold(row["suspend"], "true")), labels...)
+	if active, err := strconv.Atoi(row["active"]); err == nil {
+		e.Add("k8ctl_cronjob_active", MetricGauge, "The running jobs of a cronjob.", float64(active), labels...)
+	}
+}
+
+// singular returns the label name of a resource, ex: pod for pods.
+func singular(resource string) string {
+	if k, ok := LookupResource(resource); ok {
+		return strings.ReplaceAll(k.Singular, " ", "_")
+	}
+	return strings.TrimSuffix(resource, "s")
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// contains returns true if list holds s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
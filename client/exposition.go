@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExpositionContentType is the content type of the Prometheus text format.
+const ExpositionContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Prometheus metric types.
+const (
+	MetricGauge   = "gauge"
+	MetricCounter = "counter"
+)
+
+// Sample is a value of a metric with its labels.
+type Sample struct {
+	Labels []string // Label names and values, in pairs.
+	Value  float64  // The value.
+}
+
+// MetricFamily is a metric and its samples.
+type MetricFamily struct {
+	Name    string   // The metric name, ex: k8ctl_pod_restarts_total.
+	Type    string   // MetricGauge or MetricCounter.
+	Help    string   // What the metric measures.
+	Samples []Sample // The samples.
+}
+
+// Exposition is a set of metrics written in the Prometheus text format.
+type Exposition struct {
+	families map[string]*MetricFamily
+}
+
+// NewExposition returns an empty set of metrics.
+func NewExposition() *Exposition {
+	return &Exposition{families: make(map[string]*MetricFamily)}
+}
+
+// Add adds a sample to a metric, declaring the metric on its first sample. labels are names
+// and values in pairs, ex: "cluster", "nyc".
+func (e *Exposition) Add(name string, typ string, help string, value float64, labels ...string) {
+	f, ok := e.families[name]
+	if !ok {
+		f = &MetricFamily{Name: name, Type: typ, Help: help}
+		e.families[name] = f
+	}
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+}
+
+// Merge adds the samples of other, ex: of another cluster.
+func (e *Exposition) Merge(other *Exposition) {
+	if other == nil {
+		return
+	}
+	for _, f := range other.Families() {
+		for _, s := range f.Samples {
+			e.Add(f.Name, f.Type, f.Help, s.Value, s.Labels...)
+		}
+	}
+}
+
+// Families returns the metrics sorted by name.
+func (e *Exposition) Families() []*MetricFamily {
+	var families []*MetricFamily
+	for _, f := range e.families {
+		families = append(families, f)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (e *Exposition) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range e.Families() {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			bw.WriteString(f.Name)
+			if len(s.Labels) > 1 {
+				bw.WriteByte('{')
+				for i := 0; i+1 < len(s.Labels); i += 2 {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", s.Labels[i], escapeLabel(s.Labels[i+1]))
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(formatSampleValue(s.Value))
+			bw.WriteByte('\n')
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes the help of a metric.
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// escapeLabel escapes a label value.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// formatSampleValue formats a value as Prometheus parses it.
+func formatSampleValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestExpositionWriteTo(t *testing.T) {
+	e := NewExposition()
+	e.Add("k8ctl_pod_restarts_total", MetricCounter, "Restarts of a pod.", 4, "cluster", "nyc", "pod", "web-1")
+	e.Add("k8ctl_deployment_replicas", MetricGauge, "The replicas of a deployment.", 2, "cluster", "nyc",
+		"deployment", "web")
+	e.Add("k8ctl_up", MetricGauge, "Whether the server answered.", 1)
+
+	var buf bytes.Buffer
+	n, err := e.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	want := `# HELP k8ctl_deployment_replicas The replicas of a deployment.
+# TYPE k8ctl_deployment_replicas gauge
+k8ctl_deployment_replicas{cluster="nyc",deployment="web"} 2
+# HELP k8ctl_pod_restarts_total Restarts of a pod.
+# TYPE k8ctl_pod_restarts_total counter
+k8ctl_pod_restarts_total{cluster="nyc",pod="web-1"} 4
+# HELP k8ctl_up Whether the server answered.
+# TYPE k8ctl_up gauge
+k8ctl_up 1
+`
+	if buf.String() != want {
+		t.Errorf("WriteTo wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo = %d bytes, wrote %d", n, buf.Len())
+	}
+}
+
+func TestExpositionEscaping(t *testing.T) {
+	e := NewExposition()
+	e.Add("k8ctl_info", MetricGauge, "A back\\slash\nand a newline.", 1, "memo", "say \"hi\"\\\n")
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	want := `# HELP k8ctl_info A back\\slash\nand a newline.
+# TYPE k8ctl_info gauge
+k8ctl_info{memo="say \"hi\"\\\n"} 1
+`
+	if buf.String() != want {
+		t.Errorf("WriteTo wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatSampleValue(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{3, "3"},
+		{0.25, "0.25"},
+		{1e21, "1e+21"},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+	}
+	for _, tt := range tests {
+		if got := formatSampleValue(tt.v); got != tt.want {
+			t.Errorf("formatSampleValue(%v) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestExpositionMerge(t *testing.T) {
+	e := NewExposition()
+	e.Add("k8ctl_up", MetricGauge, "Whether the server answered.", 1, "cluster", "nyc")
+	other := NewExposition()
+	other.Add("k8ctl_up", MetricGauge, "Whether the server answered.", 0, "cluster", "sfo")
+	other.Add("k8ctl_release_revision", MetricGauge, "The current revision of a release.", 3, "cluster", "sfo")
+	e.Merge(other)
+	e.Merge(nil)
+
+	families := e.Families()
+	if len(families) != 2 || families[0].Name != "k8ctl_release_revision" || families[1].Name != "k8ctl_up" {
+		t.Fatalf("Families = %v, want k8ctl_release_revision and k8ctl_up", families)
+	}
+	if samples := families[1].Samples; len(samples) != 2 || samples[1].Labels[1] != "sfo" {
+		t.Errorf("k8ctl_up samples = %v, want nyc then sfo", samples)
+	}
+}
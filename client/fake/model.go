@@ -0,0 +1,371 @@
+package fake
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+)
+
+// Model is the in-memory state of the fake server. Tests change it through Server.Update.
+type Model struct {
+	ServerVersion      string                     // The version reported by discovery.
+	Guide              string                     // The text of the guide.
+	Resources          []client.APIResource       // The resources reported by discovery.
+	Releases           map[string]*Release        // Helm releases keyed by release name, ex: myapp-dev.
+	Objects            map[string][]*Object       // Kube resources keyed by resource kind, ex: pods.
+	Metrics            []client.PodMetrics        // Resource usage of pods.
+	Freezes            []client.Freeze            // Freezes set on the server.
+	Approvals          []*client.Approval         // Deploys waiting for, or given, approval.
+	ApprovalNamespaces []string                   // Namespaces whose deploys must be approved.
+	Rollouts           map[string]*client.Rollout // Canary and blue/green rollouts keyed by release name.
+	FailingTags        map[string]bool            // Version tags whose deploys and rollouts fail.
+	Now                func() time.Time           // The clock of the server.
+}
+
+// Release is a helm release and its revisions.
+type Release struct {
+	client.Release
+	Revisions []*Revision // Oldest first.
+}
+
+// Revision is a revision of a release along with its rendered content.
+type Revision struct {
+	client.HistoryEntry
+	VersionTag string // The docker version tag of the revision.
+	Values     string // The values the chart was rendered with.
+	Manifest   string // The rendered kube manifests.
+	Notes      string // The notes of the chart.
+}
+
+// revision returns a revision of the release, or the current one if n is 0.
+func (r *Release) revision(n int) *Revision {
+	if n == 0 {
+		n = r.Revision
+	}
+	for _, rev := range r.Revisions {
+		if rev.Revision == n {
+			return rev
+		}
+	}
+	return nil
+}
+
+// Object is a kube resource of a namespace.
+type Object struct {
+	Kind      string            // The resource kind, ex: pods.
+	Name      string            // The resource name.
+	Namespace string            // The namespace of the resource.
+	Release   string            // The release that created the resource, if any.
+	Created   time.Time         // When the resource was created; AGE is computed from it.
+	Fields    map[string]string // Values of the list columns keyed by header, ex: READY, and of other fields.
+	Data      map[string]string // The data of a configmap or secret.
+}
+
+// NewModel returns an empty cluster. Discovery reports every registered resource kind.
+func NewModel() *Model {
+	m := &Model{
+		ServerVersion: "1.0.0",
+		Guide:         "k8ctl-server fake guide.\n\nThis server holds its state in memory for testing.\n",
+		Releases:      map[string]*Release{},
+		Objects:       map[string][]*Object{},
+		Rollouts:      map[string]*client.Rollout{},
+		FailingTags:   map[string]bool{},
+		Now:           time.Now,
+	}
+	for _, k := range client.Resources() {
+		r := client.APIResource{Name: k.Name, Versions: []string{k.APIVersion}}
+		for _, v := range k.Verbs {
+			r.Verbs = append(r.Verbs, string(v))
+		}
+		m.Resources = append(m.Resources, r)
+	}
+	for _, name := range []string{"releases", "rollouts", "approvals", "freezes", "metrics", "guide", "discovery"} {
+		m.Resources = append(m.Resources, client.APIResource{Name: name, Versions: []string{apiVersion}})
+	}
+	sort.Slice(m.Resources, func(i, j int) bool { return m.Resources[i].Name < m.Resources[j].Name })
+	return m
+}
+
+// ReleaseName returns the name of the release of an application in a namespace, ex: myapp-dev.
+func ReleaseName(name string, namespace string) string {
+	if strings.HasSuffix(name, "-"+namespace) {
+		return name
+	}
+	return fmt.Sprintf("%s-%s", name, namespace)
+}
+
+// Deploy installs or upgrades the release of an application in a namespace. The deploy fails
+// if the version tag is in FailingTags. The deployment, service and pods of the release are
+// replaced.
+func (m *Model) Deploy(name string, namespace string, tag string, memo string) *Release {
+	release := ReleaseName(name, namespace)
+	r, ok := m.Releases[release]
+	if !ok {
+		r = &Release{Release: client.Release{Name: release, Namespace: namespace}}
+		m.Releases[release] = r
+	}
+	description := "Install complete"
+	if ok {
+		description = "Upgrade complete"
+	}
+	if memo != "" {
+		description = memo
+	}
+	status := client.ReleaseDeployed
+	if m.FailingTags[tag] {
+		status = client.ReleaseFailed
+		description = fmt.Sprintf("Upgrade %q failed: pods did not become ready", release)
+	}
+	m.addRevision(r, &Revision{
+		HistoryEntry: client.HistoryEntry{Status: status, Description: description},
+		VersionTag:   tag,
+		Values:       renderValues(tag),
+		Manifest:     renderManifest(release, namespace, tag),
+		Notes:        fmt.Sprintf("%s is deployed to %s with tag %s.\n", name, namespace, tag),
+	})
+	return r
+}
+
+// Rollback creates a revision of a release from an earlier one, or the one before the current
+// revision if revision is 0.
+func (m *Model) Rollback(release string, revision int, memo string) (*Release, error) {
+	r, ok := m.Releases[release]
+	if !ok {
+		return nil, fmt.Errorf("release %s not found", release)
+	}
+	if revision == 0 {
+		revision = r.Revision - 1
+	}
+	target := r.revision(revision)
+	if target == nil || revision == r.Revision {
+		return nil, fmt.Errorf("release %s has no revision %d to roll back to", release, revision)
+	}
+	description := fmt.Sprintf("Rollback to %d", revision)
+	if memo != "" {
+		description = fmt.Sprintf("%s: %s", description, memo)
+	}
+	m.addRevision(r, &Revision{
+		HistoryEntry: client.HistoryEntry{Status: client.ReleaseDeployed, Description: description},
+		VersionTag:   target.VersionTag,
+		Values:       target.Values,
+		Manifest:     target.Manifest,
+		Notes:        target.Notes,
+	})
+	return r, nil
+}
+
+// DeleteRelease removes a release and the resources it created.
+func (m *Model) DeleteRelease(release string) error {
+	r, ok := m.Releases[release]
+	if !ok {
+		return fmt.Errorf("release %s not found", release)
+	}
+	delete(m.Releases, release)
+	delete(m.Rollouts, release)
+	m.removeReleaseObjects(r.Name)
+	return nil
+}
+
+// AddObject adds a kube resource to a namespace and returns it. fields are the values of the
+// list columns of the kind, ex: READY, and may be changed later through the returned Object.
+func (m *Model) AddObject(kind string, namespace string, name string, fields map[string]string) *Object {
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	o := &Object{Kind: kind, Name: name, Namespace: namespace, Created: m.Now(), Fields: fields,
+		Data: map[string]string{}}
+	m.RemoveObject(kind, namespace, name)
+	m.Objects[kind] = append(m.Objects[kind], o)
+	return o
+}
+
+// Object returns a kube resource of a namespace, or nil.
+func (m *Model) Object(kind string, namespace string, name string) *Object {
+	for _, o := range m.Objects[kind] {
+		if o.Name == name && o.Namespace == namespace {
+			return o
+		}
+	}
+	return nil
+}
+
+// RemoveObject removes a kube resource of a namespace.
+func (m *Model) RemoveObject(kind string, namespace string, name string) {
+	list := m.Objects[kind][:0]
+	for _, o := range m.Objects[kind] {
+		if o.Name != name || o.Namespace != namespace {
+			list = append(list, o)
+		}
+	}
+	m.Objects[kind] = list
+}
+
+// SetFreeze freezes a namespace, or all with *, until a time.
+func (m *Model) SetFreeze(namespace string, until time.Time, reason string, user string) {
+	m.ClearFreeze(namespace)
+	m.Freezes = append(m.Freezes, client.Freeze{Namespace: namespace, Until: until, Reason: reason, User: user})
+}
+
+// ClearFreeze removes the freeze of a namespace and returns false if there was none.
+func (m *Model) ClearFreeze(namespace string) bool {
+	found := false
+	list := m.Freezes[:0]
+	for _, f := range m.Freezes {
+		if f.Namespace == namespace {
+			found = true
+			continue
+		}
+		list = append(list, f)
+	}
+	m.Freezes = list
+	return found
+}
+
+// RequiresApproval returns true if deploys to the namespace must be approved.
+func (m *Model) RequiresApproval(namespace string) bool {
+	return contains(m.ApprovalNamespaces, namespace)
+}
+
+// Approval returns an approval request, or nil.
+func (m *Model) Approval(id string) *client.Approval {
+	for _, a := range m.Approvals {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// addRevision makes a revision the current one of a release and supersedes the previous one.
+func (m *Model) addRevision(r *Release, rev *Revision) {
+	now := m.Now()
+	if cur := r.revision(0); cur != nil && cur.Status == client.ReleaseDeployed {
+		cur.Status = "superseded"
+	}
+	rev.Revision = r.Revision + 1
+	rev.Updated = now
+	rev.Chart = chartName(r.Name, r.Namespace)
+	rev.AppVersion = rev.VersionTag
+	r.Revisions = append(r.Revisions, rev)
+	r.Revision = rev.Revision
+	r.Updated = now
+	r.Status = rev.Status
+	r.Chart = rev.Chart
+	r.AppVersion = rev.AppVersion
+	r.VersionTag = rev.VersionTag
+	m.deployObjects(r)
+}
+
+// deployObjects replaces the deployment, service and pods of a release with ones running its
+// current revision. Pods of a failed revision do not become ready.
+func (m *Model) deployObjects(r *Release) {
+	m.removeReleaseObjects(r.Name)
+	ready, status, restarts := "1/1", "Running", "0"
+	available := "2"
+	endpoints := fmt.Sprintf("10.1.%d.1:80,10.1.%d.2:80", r.Revision%256, r.Revision%256)
+	if r.Status == client.ReleaseFailed {
+		ready, status, restarts, available, endpoints = "0/1", "CrashLoopBackOff", "4", "0", "<none>"
+	}
+	d := m.AddObject("deployments", r.Namespace, r.Name, map[string]string{
+		"READY": available + "/2", "UP-TO-DATE": "2", "AVAILABLE": available})
+	d.Release = r.Name
+	s := m.AddObject("services", r.Namespace, r.Name, map[string]string{
+		"TYPE": "ClusterIP", "CLUSTER-IP": fmt.Sprintf("10.0.%d.%d", len(r.Name)%256, r.Revision%256),
+		"EXTERNAL-IP": "<none>", "PORTS": "80/TCP", "Endpoints": endpoints})
+	s.Release = r.Name
+	hash := randomHex(5)
+	for i := 0; i < 2; i++ {
+		p := m.AddObject("pods", r.Namespace, fmt.Sprintf("%s-%s-%s", r.Name, hash, randomHex(3)), map[string]string{
+			"READY": ready, "STATUS": status, "RESTARTS": restarts})
+		p.Release = r.Name
+		m.Metrics = append(m.Metrics, client.PodMetrics{Name: p.Name, Namespace: p.Namespace,
+			Containers: []client.ContainerMetrics{{Name: "app", CPU: 5, Memory: 64 << 20, CPURequest: 100,
+				CPULimit: 500, MemoryRequest: 128 << 20, MemoryLimit: 256 << 20}}})
+	}
+}
+
+// removeReleaseObjects removes the resources and pod metrics of a release.
+func (m *Model) removeReleaseObjects(release string) {
+	pods := map[string]bool{}
+	for kind, list := range m.Objects {
+		kept := list[:0]
+		for _, o := range list {
+			if o.Release == release {
+				if kind == "pods" {
+					pods[o.Namespace+"/"+o.Name] = true
+				}
+				continue
+			}
+			kept = append(kept, o)
+		}
+		m.Objects[kind] = kept
+	}
+	metrics := m.Metrics[:0]
+	for _, p := range m.Metrics {
+		if !pods[p.Namespace+"/"+p.Name] {
+			metrics = append(metrics, p)
+		}
+	}
+	m.Metrics = metrics
+}
+
+// chartName returns the chart and version of a release.
+func chartName(release string, namespace string) string {
+	return fmt.Sprintf("%s-0.1.0", strings.TrimSuffix(release, "-"+namespace))
+}
+
+// renderValues returns the values of a deploy of a version tag.
+func renderValues(tag string) string {
+	return fmt.Sprintf("image:\n  tag: %s\nreplicaCount: 2\n", tag)
+}
+
+// renderManifest returns the manifest of a deploy of a version tag.
+func renderManifest(release string, namespace string, tag string) string {
+	return fmt.Sprintf(`---
+apiVersion: v1
+kind: Service
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+spec:
+  ports:
+  - port: 80
+    targetPort: 8080
+  selector:
+    app: %[1]s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        image: registry.local/%[1]s:%[3]s
+        env:
+        - name: NAMESPACE
+          value: %[2]s
+        resources:
+          requests:
+            cpu: 100m
+            memory: 128Mi
+          limits:
+            cpu: 500m
+            memory: 256Mi
+`, release, namespace, tag)
+}
+
+// randomHex returns n random bytes as lower case hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)[:n]
+}
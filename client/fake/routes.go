@@ -0,0 +1,861 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"gopkg.in/yaml.v2"
+)
+
+// route is a method and path pattern served by a handler. %s segments of the pattern are
+// passed to the handler as args.
+type route struct {
+	method  string
+	pattern string
+	handle  func(w http.ResponseWriter, r *http.Request, m *Model, args []string)
+}
+
+// match returns the %s segments of a path matching the route.
+func (rt *route) match(path string) ([]string, bool) {
+	want := strings.Split(strings.Trim(rt.pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	var args []string
+	for i := range want {
+		switch {
+		case want[i] == "%s" && got[i] != "":
+			args = append(args, got[i])
+		case want[i] != got[i]:
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// literal returns true if the route has no %s segments. A literal route takes precedence over
+// the patterns matching the same path, ex: /releases/dry-run over /releases/%s.
+func (rt *route) literal() bool {
+	return !strings.Contains(rt.pattern, "%s")
+}
+
+// buildRoutes returns the routes of the server. The resource kinds registered with the client
+// are served from the Objects of the model.
+func (s *Server) buildRoutes() []route {
+	routes := []route{
+		// Helm related
+		{http.MethodGet, "/releases", handleReleaseList},
+		{http.MethodPost, "/releases", handleDeploy},
+		{http.MethodPost, "/releases/dry-run", handleDryRun},
+		{http.MethodGet, "/releases/%s", handleReleaseStatus},
+		{http.MethodDelete, "/releases/%s", handleReleaseDelete},
+		{http.MethodPut, "/releases/%s/rollback", handleRollback},
+		{http.MethodGet, "/releases/%s/history", handleHistory},
+		{http.MethodGet, "/releases/%s/manifest", releaseContent(client.ContentManifest)},
+		{http.MethodGet, "/releases/%s/notes", releaseContent(client.ContentNotes)},
+		{http.MethodGet, "/releases/%s/values", releaseContent(client.ContentValues)},
+		{http.MethodPost, "/releases/%s/rollout", handleRolloutStart},
+		{http.MethodPatch, "/releases/%s/rollout", handleRolloutWeight},
+		{http.MethodGet, "/releases/%s/rollout", handleRolloutStatus},
+		{http.MethodDelete, "/releases/%s/rollout", handleRolloutAbort},
+		{http.MethodPut, "/releases/%s/rollout/promote", handleRolloutPromote},
+
+		// Kube related
+		{http.MethodGet, "/metrics/pods", handleMetricsPods},
+		{http.MethodGet, "/metrics/namespaces/%s", handleMetricsNamespace},
+
+		// Other
+		{http.MethodGet, "/approvals", handleApprovalList},
+		{http.MethodPost, "/approvals", handleApprovalRequest},
+		{http.MethodGet, "/approvals/%s", handleApprovalGet},
+		{http.MethodPut, "/approvals/%s/approve", handleApprove},
+		{http.MethodPut, "/approvals/%s/reject", handleReject},
+		{http.MethodGet, "/guide", handleGuide},
+		{http.MethodGet, "/api-resources", handleDiscovery},
+		{http.MethodGet, "/freezes", handleFreezeList},
+		{http.MethodPost, "/freezes", handleFreezeSet},
+		{http.MethodDelete, "/freezes/%s", handleFreezeClear},
+	}
+	for _, k := range client.Resources() {
+		k := k
+		if k.Supports(client.VerbList) {
+			routes = append(routes, route{http.MethodGet, k.ListRoute, func(w http.ResponseWriter, r *http.Request,
+				m *Model, args []string) {
+				handleObjectList(w, r, m, &k)
+			}})
+		}
+		if k.DescribeRoute != "" {
+			routes = append(routes, route{http.MethodGet, k.DescribeRoute, func(w http.ResponseWriter, r *http.Request,
+				m *Model, args []string) {
+				handleObjectDescribe(w, r, m, &k, args[0])
+			}})
+		}
+		if k.Supports(client.VerbRestart) {
+			routes = append(routes, route{http.MethodPatch, k.RestartRoute, func(w http.ResponseWriter, r *http.Request,
+				m *Model, args []string) {
+				handleObjectRestart(w, r, m, &k, args[0])
+			}})
+		}
+	}
+	return routes
+}
+
+// serve checks the API version of the Accept header and calls the handler of the route.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if resource, version := parseAccept(r.Header.Get("Accept")); resource != "" {
+		for _, res := range s.model.Resources {
+			if res.Name == resource && !contains(res.Versions, version) {
+				reply(w, http.StatusNotAcceptable, fmt.Sprintf("%s does not serve %s version %s", serverName, resource, version))
+				return
+			}
+		}
+	}
+	literal := false
+	for i := range s.routes {
+		if _, ok := s.routes[i].match(r.URL.Path); ok && s.routes[i].literal() {
+			literal = true
+		}
+	}
+	pathFound := false
+	for i := range s.routes {
+		rt := &s.routes[i]
+		args, ok := rt.match(r.URL.Path)
+		if !ok || (literal && !rt.literal()) {
+			continue
+		}
+		pathFound = true
+		if rt.method == r.Method {
+			rt.handle(w, r, s.model, args)
+			return
+		}
+	}
+	if pathFound {
+		reply(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed on %s", r.Method, r.URL.Path))
+		return
+	}
+	reply(w, http.StatusNotFound, fmt.Sprintf("no route %s", r.URL.Path))
+}
+
+// Helm related handlers.
+
+func handleReleaseList(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	namespace := r.URL.Query().Get("n")
+	var names []string
+	for name, rel := range m.Releases {
+		if namespace == "" || rel.Namespace == namespace {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	releases := []client.Release{}
+	var rows [][]string
+	for _, name := range names {
+		rel := m.Releases[name].Release
+		releases = append(releases, rel)
+		rows = append(rows, []string{rel.Name, strconv.Itoa(rel.Revision), rel.Updated.Format(time.ANSIC), rel.Status,
+			rel.Chart, rel.AppVersion, rel.Namespace})
+	}
+	replyObject(w, r, releases, func() string {
+		return table([]string{"NAME", "REVISION", "UPDATED", "STATUS", "CHART", "APP VERSION", "NAMESPACE"}, rows)
+	})
+}
+
+func handleDeploy(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var dr client.DeployRequest
+	if !decodeBody(w, r, &dr) {
+		return
+	}
+	if dr.Name == "" || dr.Namespace == "" || dr.VersionTag == "" {
+		reply(w, http.StatusBadRequest, "name, namespace and versionTag are required")
+		return
+	}
+	if !approved(w, m, dr.Namespace) {
+		return
+	}
+	rel := m.Deploy(dr.Name, dr.Namespace, dr.VersionTag, dr.Memo)
+	reply(w, http.StatusCreated, fmt.Sprintf("Release %s revision %d of %s is %s in namespace %s.", rel.Name, rel.Revision,
+		dr.VersionTag, rel.Status, rel.Namespace))
+}
+
+func handleDryRun(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var dr client.DeployRequest
+	if !decodeBody(w, r, &dr) {
+		return
+	}
+	if dr.Name == "" || dr.Namespace == "" || dr.VersionTag == "" {
+		reply(w, http.StatusBadRequest, "name, namespace and versionTag are required")
+		return
+	}
+	release := ReleaseName(dr.Name, dr.Namespace)
+	d := client.DryRun{Release: release, Planned: renderManifest(release, dr.Namespace, dr.VersionTag)}
+	if rel, ok := m.Releases[release]; ok {
+		d.Current = rel.revision(0).Manifest
+	}
+	replyJSON(w, http.StatusOK, d)
+}
+
+func handleReleaseStatus(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	rel, ok := findRelease(w, m, args[0])
+	if !ok {
+		return
+	}
+	replyObject(w, r, rel.Release, func() string {
+		return fmt.Sprintf("LAST DEPLOYED: %s\nNAMESPACE: %s\nSTATUS: %s\nREVISION: %d\nCHART: %s\nTAG: %s\n",
+			rel.Updated.Format(time.ANSIC), rel.Namespace, strings.ToUpper(rel.Status), rel.Revision, rel.Chart,
+			rel.VersionTag)
+	})
+}
+
+func handleReleaseDelete(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	if err := m.DeleteRelease(args[0]); err != nil {
+		reply(w, http.StatusNotFound, err.Error())
+		return
+	}
+	reply(w, http.StatusOK, fmt.Sprintf("Release %s deleted.", args[0]))
+}
+
+func handleRollback(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var rr client.RollbackRequest
+	if !decodeBody(w, r, &rr) {
+		return
+	}
+	if _, ok := findRelease(w, m, args[0]); !ok {
+		return
+	}
+	revision := 0
+	if rr.Revision != "" {
+		var err error
+		if revision, err = strconv.Atoi(rr.Revision); err != nil {
+			reply(w, http.StatusBadRequest, fmt.Sprintf("invalid revision %q", rr.Revision))
+			return
+		}
+	}
+	rel, err := m.Rollback(args[0], revision, rr.Memo)
+	if err != nil {
+		reply(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reply(w, http.StatusOK, fmt.Sprintf("Rollback was a success! Release %s is at revision %d.", rel.Name, rel.Revision))
+}
+
+func handleHistory(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	rel, ok := findRelease(w, m, args[0])
+	if !ok {
+		return
+	}
+	entries := []client.HistoryEntry{}
+	var rows [][]string
+	for _, rev := range rel.Revisions {
+		entries = append(entries, rev.HistoryEntry)
+		rows = append(rows, []string{strconv.Itoa(rev.Revision), rev.Updated.Format(time.ANSIC), rev.Status, rev.Chart,
+			rev.Description})
+	}
+	replyObject(w, r, entries, func() string {
+		return table([]string{"REVISION", "UPDATED", "STATUS", "CHART", "DESCRIPTION"}, rows)
+	})
+}
+
+// releaseContent returns the handler of the values, manifest or notes of a revision.
+func releaseContent(content string) func(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	return func(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+		rel, ok := findRelease(w, m, args[0])
+		if !ok {
+			return
+		}
+		n := 0
+		if s := r.URL.Query().Get("r"); s != "" {
+			n, _ = strconv.Atoi(s)
+		}
+		rev := rel.revision(n)
+		if rev == nil {
+			reply(w, http.StatusNotFound, fmt.Sprintf("release %s has no revision %s", rel.Name, r.URL.Query().Get("r")))
+			return
+		}
+		switch content {
+		case client.ContentValues:
+			reply(w, http.StatusOK, rev.Values)
+		case client.ContentManifest:
+			reply(w, http.StatusOK, rev.Manifest)
+		default:
+			reply(w, http.StatusOK, rev.Notes)
+		}
+	}
+}
+
+func handleRolloutStart(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var rr client.RolloutRequest
+	if !decodeBody(w, r, &rr) {
+		return
+	}
+	if rr.Namespace == "" || rr.VersionTag == "" {
+		reply(w, http.StatusBadRequest, "namespace and versionTag are required")
+		return
+	}
+	if rr.Strategy != client.StrategyCanary && rr.Strategy != client.StrategyBlueGreen {
+		reply(w, http.StatusBadRequest, fmt.Sprintf("unknown strategy %q", rr.Strategy))
+		return
+	}
+	if !approved(w, m, rr.Namespace) {
+		return
+	}
+	release := ReleaseName(args[0], rr.Namespace)
+	rel, ok := findRelease(w, m, release)
+	if !ok {
+		return
+	}
+	if ro, ok := m.Rollouts[release]; ok && (ro.Phase == client.RolloutProgressing || ro.Phase == client.RolloutPaused) {
+		reply(w, http.StatusConflict, fmt.Sprintf("a rollout of %s to %s is in progress", release, ro.NewTag))
+		return
+	}
+	ro := &client.Rollout{Release: release, Namespace: rr.Namespace, Strategy: rr.Strategy, StableTag: rel.VersionTag,
+		NewTag: rr.VersionTag, Replicas: 2}
+	m.Rollouts[release] = ro
+	m.setRolloutWeight(ro, rr.Weight)
+	reply(w, http.StatusCreated, fmt.Sprintf("Rollout of %s to %s started at %d%%.", release, rr.VersionTag, ro.Weight))
+}
+
+func handleRolloutWeight(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var rr client.RolloutRequest
+	if !decodeBody(w, r, &rr) {
+		return
+	}
+	ro, ok := findRollout(w, m, ReleaseName(args[0], rr.Namespace), true)
+	if !ok {
+		return
+	}
+	if rr.Weight < 0 || rr.Weight > 100 {
+		reply(w, http.StatusBadRequest, fmt.Sprintf("invalid weight %d", rr.Weight))
+		return
+	}
+	m.setRolloutWeight(ro, rr.Weight)
+	reply(w, http.StatusOK, fmt.Sprintf("Rollout of %s to %s is at %d%%.", ro.Release, ro.NewTag, ro.Weight))
+}
+
+func handleRolloutStatus(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	ro, ok := findRollout(w, m, ReleaseName(args[0], r.URL.Query().Get("n")), false)
+	if !ok {
+		return
+	}
+	replyObject(w, r, ro, func() string {
+		return fmt.Sprintf("RELEASE: %s\nSTRATEGY: %s\nPHASE: %s\nSTABLE: %s\nNEW: %s\nWEIGHT: %d%%\nREADY: %d/%d\n",
+			ro.Release, ro.Strategy, ro.Phase, ro.StableTag, ro.NewTag, ro.Weight, ro.ReadyReplicas, ro.Replicas)
+	})
+}
+
+func handleRolloutAbort(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	ro, ok := findRollout(w, m, ReleaseName(args[0], r.URL.Query().Get("n")), false)
+	if !ok {
+		return
+	}
+	if ro.Phase == client.RolloutPromoted || ro.Phase == client.RolloutAborted {
+		reply(w, http.StatusConflict, fmt.Sprintf("rollout of %s is %s", ro.Release, ro.Phase))
+		return
+	}
+	ro.Phase, ro.Weight, ro.Updated = client.RolloutAborted, 0, m.Now()
+	ro.Message = "aborted"
+	if memo := r.URL.Query().Get("m"); memo != "" {
+		ro.Message = memo
+	}
+	reply(w, http.StatusOK, fmt.Sprintf("Rollout of %s to %s aborted; %s serves all traffic.", ro.Release, ro.NewTag,
+		ro.StableTag))
+}
+
+func handleRolloutPromote(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var rr client.RolloutRequest
+	if !decodeBody(w, r, &rr) {
+		return
+	}
+	ro, ok := findRollout(w, m, ReleaseName(args[0], rr.Namespace), true)
+	if !ok {
+		return
+	}
+	if !ro.Healthy {
+		reply(w, http.StatusConflict, fmt.Sprintf("rollout of %s to %s is not healthy", ro.Release, ro.NewTag))
+		return
+	}
+	rel := m.Deploy(args[0], ro.Namespace, ro.NewTag, rr.Memo)
+	ro.Phase, ro.Weight, ro.StableTag, ro.Updated = client.RolloutPromoted, 100, ro.NewTag, m.Now()
+	reply(w, http.StatusOK, fmt.Sprintf("Rollout of %s promoted; revision %d serves all traffic.", rel.Name, rel.Revision))
+}
+
+// setRolloutWeight moves a rollout to a weight. The new version of a failing tag never becomes
+// healthy.
+func (m *Model) setRolloutWeight(ro *client.Rollout, weight int) {
+	ro.Weight, ro.Updated = weight, m.Now()
+	if m.FailingTags[ro.NewTag] {
+		ro.Phase, ro.Healthy, ro.ReadyReplicas = client.RolloutFailed, false, 0
+		ro.Message = fmt.Sprintf("pods of %s are not ready", ro.NewTag)
+		return
+	}
+	ro.Phase, ro.Healthy, ro.ReadyReplicas, ro.Message = client.RolloutPaused, true, ro.Replicas, ""
+}
+
+// Kube related handlers.
+
+func handleObjectList(w http.ResponseWriter, r *http.Request, m *Model, k *client.ResourceKind) {
+	namespace := r.URL.Query().Get("n")
+	objects := []map[string]string{}
+	var rows [][]string
+	for _, o := range sortedObjects(m.Objects[k.Name]) {
+		if namespace != "" && o.Namespace != namespace {
+			continue
+		}
+		row := make([]string, len(k.Columns))
+		obj := map[string]string{"namespace": o.Namespace}
+		for i, c := range k.Columns {
+			row[i] = m.column(o, c)
+			obj[strings.ToLower(c)] = row[i]
+		}
+		objects = append(objects, obj)
+		rows = append(rows, row)
+	}
+	replyObject(w, r, objects, func() string {
+		if len(rows) == 0 {
+			return fmt.Sprintf("No resources found in %s namespace.", namespace)
+		}
+		return table(k.Columns, rows)
+	})
+}
+
+func handleObjectDescribe(w http.ResponseWriter, r *http.Request, m *Model, k *client.ResourceKind, name string) {
+	q := r.URL.Query()
+	o := m.Object(k.Name, q.Get("n"), name)
+	if o == nil {
+		reply(w, http.StatusNotFound, fmt.Sprintf("%s %s not found in namespace %s", k.Singular, name, q.Get("n")))
+		return
+	}
+	reveal := q.Get("reveal")
+	if reveal != "" {
+		if _, ok := o.Data[reveal]; !ok {
+			reply(w, http.StatusNotFound, fmt.Sprintf("%s %s has no key %s", k.Singular, name, reveal))
+			return
+		}
+	}
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", o.Name)
+	fmt.Fprintf(tw, "Namespace:\t%s\n", o.Namespace)
+	fmt.Fprintf(tw, "Created:\t%s\n", o.Created.Format(time.RFC1123Z))
+	columns := map[string]bool{}
+	for _, c := range k.Columns {
+		columns[c] = true
+		if c != "NAME" && c != "AGE" {
+			fmt.Fprintf(tw, "%s:\t%s\n", c, m.column(o, c))
+		}
+	}
+	for _, f := range sortedKeys(o.Fields) {
+		if !columns[f] {
+			fmt.Fprintf(tw, "%s:\t%s\n", f, o.Fields[f])
+		}
+	}
+	if o.Release != "" {
+		fmt.Fprintf(tw, "Release:\t%s\n", o.Release)
+	}
+	tw.Flush()
+	if len(o.Data) > 0 {
+		b.WriteString("\nData\n====\n")
+		for _, key := range sortedKeys(o.Data) {
+			value := o.Data[key]
+			if k.Name == "secrets" && key != reveal {
+				value = fmt.Sprintf("<redacted, %d bytes>", len(value))
+			}
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+	}
+	reply(w, http.StatusOK, strings.TrimRight(b.String(), "\n"))
+}
+
+func handleObjectRestart(w http.ResponseWriter, r *http.Request, m *Model, k *client.ResourceKind, name string) {
+	var rr client.RestartRequest
+	if !decodeBody(w, r, &rr) {
+		return
+	}
+	o := m.Object(k.Name, rr.Namespace, name)
+	if o == nil {
+		reply(w, http.StatusNotFound, fmt.Sprintf("%s %s not found in namespace %s", k.Singular, name, rr.Namespace))
+		return
+	}
+	// Pods of the release are replaced by new ones.
+	if rel, ok := m.Releases[o.Release]; ok {
+		m.deployObjects(rel)
+	}
+	reply(w, http.StatusOK, fmt.Sprintf("%s %s restarted in namespace %s.", k.Singular, name, rr.Namespace))
+}
+
+func handleMetricsPods(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	namespace := r.URL.Query().Get("n")
+	pods := []client.PodMetrics{}
+	var rows [][]string
+	for _, p := range m.Metrics {
+		if namespace == "" || p.Namespace == namespace {
+			pods = append(pods, p)
+			t := p.Total()
+			rows = append(rows, []string{p.Name, client.FormatCPU(t.CPU), client.FormatMemory(t.Memory)})
+		}
+	}
+	replyObject(w, r, pods, func() string {
+		return table([]string{"NAME", "CPU", "MEMORY"}, rows)
+	})
+}
+
+func handleMetricsNamespace(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	ns := client.NamespaceMetrics{Namespace: args[0]}
+	for _, p := range m.Metrics {
+		if p.Namespace != args[0] {
+			continue
+		}
+		t := p.Total()
+		ns.Pods++
+		ns.CPU += t.CPU
+		ns.Memory += t.Memory
+		ns.CPURequest += t.CPURequest
+		ns.CPULimit += t.CPULimit
+		ns.MemoryRequest += t.MemoryRequest
+		ns.MemoryLimit += t.MemoryLimit
+	}
+	replyObject(w, r, ns, func() string {
+		return table([]string{"NAMESPACE", "PODS", "CPU", "MEMORY"}, [][]string{{ns.Namespace, strconv.Itoa(ns.Pods),
+			client.FormatCPU(ns.CPU), client.FormatMemory(ns.Memory)}})
+	})
+}
+
+// Other handlers.
+
+func handleApprovalList(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	q := r.URL.Query()
+	approvals := []client.Approval{}
+	var rows [][]string
+	for _, a := range m.Approvals {
+		if (q.Get("n") == "" || a.Namespace == q.Get("n")) && (q.Get("s") == "" || a.Status == q.Get("s")) {
+			approvals = append(approvals, *a)
+			rows = append(rows, []string{a.ID, a.Release, a.Namespace, a.VersionTag, a.Requester, a.Status})
+		}
+	}
+	replyObject(w, r, approvals, func() string {
+		return table([]string{"ID", "RELEASE", "NAMESPACE", "TAG", "REQUESTER", "STATUS"}, rows)
+	})
+}
+
+func handleApprovalRequest(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var dr client.DeployRequest
+	if !decodeBody(w, r, &dr) {
+		return
+	}
+	if dr.Name == "" || dr.Namespace == "" || dr.VersionTag == "" {
+		reply(w, http.StatusBadRequest, "name, namespace and versionTag are required")
+		return
+	}
+	a := &client.Approval{ID: strings.ToUpper(randomHex(8)), Release: dr.Name, Namespace: dr.Namespace,
+		VersionTag: dr.VersionTag, Memo: dr.Memo, Requester: requestUser(r), RequestedAt: m.Now(),
+		Status: client.ApprovalPending}
+	m.Approvals = append(m.Approvals, a)
+	replyObject(w, r, a, func() string {
+		return fmt.Sprintf("Deploy %s of %s %s is waiting for approval.", a.ID, a.Release, a.VersionTag)
+	})
+}
+
+func handleApprovalGet(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	a, ok := findApproval(w, m, args[0])
+	if !ok {
+		return
+	}
+	replyObject(w, r, a, func() string {
+		return fmt.Sprintf("ID: %s\nRELEASE: %s\nNAMESPACE: %s\nTAG: %s\nREQUESTER: %s\nSTATUS: %s\n", a.ID, a.Release,
+			a.Namespace, a.VersionTag, a.Requester, a.Status)
+	})
+}
+
+func handleApprove(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var rr client.ReviewRequest
+	if !decodeBody(w, r, &rr) {
+		return
+	}
+	a, ok := findApproval(w, m, args[0])
+	if !ok || !reviewable(w, r, a) {
+		return
+	}
+	rel := m.Deploy(a.Release, a.Namespace, a.VersionTag, a.Memo)
+	a.Status, a.Reviewer, a.ReviewedAt = client.ApprovalApproved, requestUser(r), m.Now()
+	if rel.Status == client.ReleaseFailed {
+		a.Status = client.ApprovalFailed
+	}
+	a.Message = fmt.Sprintf("Release %s revision %d of %s is %s.", rel.Name, rel.Revision, a.VersionTag, rel.Status)
+	reply(w, http.StatusOK, fmt.Sprintf("Deploy %s approved. %s", a.ID, a.Message))
+}
+
+func handleReject(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var rr client.ReviewRequest
+	if !decodeBody(w, r, &rr) {
+		return
+	}
+	a, ok := findApproval(w, m, args[0])
+	if !ok || !reviewable(w, r, a) {
+		return
+	}
+	a.Status, a.Reviewer, a.ReviewedAt, a.Reason = client.ApprovalRejected, requestUser(r), m.Now(), rr.Reason
+	reply(w, http.StatusOK, fmt.Sprintf("Deploy %s rejected.", a.ID))
+}
+
+// reviewable answers an error and returns false if the user of a request cannot review an
+// approval request.
+func reviewable(w http.ResponseWriter, r *http.Request, a *client.Approval) bool {
+	switch {
+	case a.Status != client.ApprovalPending:
+		reply(w, http.StatusConflict, fmt.Sprintf("deploy %s is already %s", a.ID, a.Status))
+	case requestUser(r) == a.Requester:
+		reply(w, http.StatusForbidden, fmt.Sprintf("deploy %s cannot be reviewed by its requester", a.ID))
+	default:
+		return true
+	}
+	return false
+}
+
+func handleGuide(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	reply(w, http.StatusOK, m.Guide)
+}
+
+func handleDiscovery(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	d := client.Discovery{ServerVersion: m.ServerVersion, Resources: m.Resources, ApprovalNamespaces: m.ApprovalNamespaces}
+	replyObject(w, r, d, func() string {
+		var rows [][]string
+		for _, res := range m.Resources {
+			rows = append(rows, []string{res.Name, strings.Join(res.Verbs, ","), strings.Join(res.Versions, ",")})
+		}
+		return table([]string{"NAME", "VERBS", "VERSIONS"}, rows)
+	})
+}
+
+func handleFreezeList(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	namespace := r.URL.Query().Get("n")
+	freezes := []client.Freeze{}
+	var rows [][]string
+	for _, f := range m.Freezes {
+		if namespace == "" || f.Applies(namespace, m.Now()) || f.Namespace == namespace {
+			freezes = append(freezes, f)
+			rows = append(rows, []string{f.Namespace, f.Until.Format(time.RFC3339), f.User, f.Reason})
+		}
+	}
+	replyObject(w, r, freezes, func() string {
+		return table([]string{"NAMESPACE", "UNTIL", "USER", "REASON"}, rows)
+	})
+}
+
+func handleFreezeSet(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	var fr client.FreezeRequest
+	if !decodeBody(w, r, &fr) {
+		return
+	}
+	if fr.Namespace == "" || fr.Until.IsZero() {
+		reply(w, http.StatusBadRequest, "namespace and until are required")
+		return
+	}
+	m.SetFreeze(fr.Namespace, fr.Until, fr.Reason, fr.User)
+	reply(w, http.StatusCreated, fmt.Sprintf("Namespace %s is frozen until %s.", fr.Namespace, fr.Until.Format(time.RFC3339)))
+}
+
+func handleFreezeClear(w http.ResponseWriter, r *http.Request, m *Model, args []string) {
+	if !m.ClearFreeze(args[0]) {
+		reply(w, http.StatusNotFound, fmt.Sprintf("namespace %s is not frozen", args[0]))
+		return
+	}
+	reply(w, http.StatusOK, fmt.Sprintf("Freeze of namespace %s cleared.", args[0]))
+}
+
+// Helpers.
+
+// findRelease answers not found and returns false if the release does not exist.
+func findRelease(w http.ResponseWriter, m *Model, release string) (*Release, bool) {
+	rel, ok := m.Releases[release]
+	if !ok {
+		reply(w, http.StatusNotFound, fmt.Sprintf("release %s not found", release))
+	}
+	return rel, ok
+}
+
+// findRollout answers an error and returns false if the release has no rollout, or no active
+// rollout when active is set.
+func findRollout(w http.ResponseWriter, m *Model, release string, active bool) (*client.Rollout, bool) {
+	ro, ok := m.Rollouts[release]
+	switch {
+	case !ok:
+		reply(w, http.StatusNotFound, fmt.Sprintf("release %s has no rollout", release))
+		return nil, false
+	case active && ro.Phase != client.RolloutProgressing && ro.Phase != client.RolloutPaused:
+		reply(w, http.StatusConflict, fmt.Sprintf("rollout of %s is %s", release, ro.Phase))
+		return nil, false
+	}
+	return ro, true
+}
+
+// approved answers forbidden and returns false if deploys to the namespace must be approved.
+func approved(w http.ResponseWriter, m *Model, namespace string) bool {
+	if m.RequiresApproval(namespace) {
+		reply(w, http.StatusForbidden, fmt.Sprintf("namespace %s requires approval: request the deploy on /approvals",
+			namespace))
+		return false
+	}
+	return true
+}
+
+// findApproval answers not found and returns false if the approval request does not exist.
+func findApproval(w http.ResponseWriter, m *Model, id string) (*client.Approval, bool) {
+	a := m.Approval(id)
+	if a == nil {
+		reply(w, http.StatusNotFound, fmt.Sprintf("approval %s not found", id))
+	}
+	return a, a != nil
+}
+
+// column returns the value of a list column of an object.
+func (m *Model) column(o *Object, c string) string {
+	switch c {
+	case "NAME":
+		return o.Name
+	case "AGE":
+		return age(m.Now().Sub(o.Created))
+	}
+	if v, ok := o.Fields[c]; ok {
+		return v
+	}
+	switch c {
+	case "DATA", "KEYS":
+		return strconv.Itoa(len(o.Data))
+	case "SIZE":
+		size := 0
+		for _, v := range o.Data {
+			size += len(v)
+		}
+		return strconv.Itoa(size)
+	case "LAST MODIFIED":
+		return o.Created.Format(time.RFC3339)
+	}
+	return "<none>"
+}
+
+// decodeBody decodes a JSON request body, or answers bad request and returns false.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		reply(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return false
+	}
+	return true
+}
+
+// reply answers a response with a message.
+func reply(w http.ResponseWriter, status int, message string) {
+	b, _ := json.Marshal(&client.Response{Status: http.StatusText(status), Message: message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// replyJSON answers a response whose message is v encoded as JSON.
+func replyJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		reply(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	reply(w, status, string(b))
+}
+
+// replyObject answers v in the format asked by the f query parameter: json, yaml, or the text
+// returned by text.
+func replyObject(w http.ResponseWriter, r *http.Request, v interface{}, text func() string) {
+	switch r.URL.Query().Get("f") {
+	case "json":
+		replyJSON(w, http.StatusOK, v)
+	case "yaml":
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			reply(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		reply(w, http.StatusOK, string(b))
+	default:
+		reply(w, http.StatusOK, text())
+	}
+}
+
+// table renders rows as aligned columns under a header.
+func table(headers []string, rows [][]string) string {
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// age formats a duration the way kubectl shows the age of a resource, ex: 5m.
+func age(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// parseAccept returns the resource and API version of an Accept header, ex:
+// application/vnd.k8ctl-server.releases-v1.0.0+json.
+func parseAccept(accept string) (string, string) {
+	prefix := fmt.Sprintf("application/vnd.%s.", serverName)
+	if !strings.HasPrefix(accept, prefix) || !strings.HasSuffix(accept, "+json") {
+		return "", ""
+	}
+	s := strings.TrimSuffix(strings.TrimPrefix(accept, prefix), "+json")
+	i := strings.LastIndex(s, "-")
+	if i < 0 {
+		return "", ""
+	}
+	return s[:i], s[i+1:]
+}
+
+// sortedObjects returns objects sorted by namespace and name.
+func sortedObjects(list []*Object) []*Object {
+	result := append([]*Object(nil), list...)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// contains returns true if a list holds a string.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
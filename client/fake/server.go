@@ -0,0 +1,293 @@
+// Package fake provides an in-process k8ctl-server for testing the client and the commands
+// without a cluster. It serves every route of the client from an in-memory Model, records the
+// requests it receives, and can be told to fail or slow down requests.
+//
+//	s := fake.NewServer()
+//	defer s.Close()
+//	s.Update(func(m *fake.Model) { m.Deploy("myapp", "dev", "k8-1.0.0-1", "") })
+//	releases, err := s.NewClient().Releases("dev")
+package fake
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+)
+
+const (
+	apiVersion   = "v1.0.0"       // The API version of the routes served.
+	serverName   = "k8ctl-server" // The server name of the Accept header.
+	DefaultToken = "fake-token"   // The bearer token accepted by a new server.
+	DefaultUser  = "fake-user"    // The user of Token.
+)
+
+// Server is a fake k8ctl-server listening on a local port.
+type Server struct {
+	URL   string            // The base URL of the server, ex: http://127.0.0.1:34567.
+	Token string            // The bearer token required; empty accepts any.
+	Users map[string]string // Users keyed by the other bearer tokens accepted, ex: to review deploys.
+
+	mu       sync.Mutex
+	model    *Model
+	failures []*Failure
+	latency  time.Duration
+	requests []Request
+	http     *httptest.Server
+	routes   []route
+}
+
+// Failure makes matching requests fail instead of being served.
+type Failure struct {
+	Method  string // The method to fail, or any if empty.
+	Path    string // The path prefix to fail, ex: /releases, or any if empty.
+	Status  int    // The HTTP status answered (default: 500).
+	Message string // The message of the response (default: the status text).
+	Body    string // A raw body answered instead of a JSON response, ex: to test bad payloads.
+	Drop    bool   // Close the connection without answering.
+	Times   int    // How many requests fail; 0 fails them until cleared.
+}
+
+// matches returns true if the failure applies to a request.
+func (f *Failure) matches(r *http.Request) bool {
+	return (f.Method == "" || f.Method == r.Method) && strings.HasPrefix(r.URL.Path, f.Path)
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string      // The HTTP method.
+	Path   string      // The URL path, ex: /releases/myapp-dev.
+	Query  url.Values  // The query parameters.
+	Header http.Header // The request headers.
+	Body   []byte      // The request body.
+	User   string      // The user of the bearer token, empty if it was refused.
+	Status int         // The HTTP status answered, 0 if the connection was dropped.
+	Time   time.Time   // When the request was received.
+}
+
+// Resource returns the resource named by the Accept header of the request, ex: releases.
+func (r *Request) Resource() string {
+	resource, _ := parseAccept(r.Header.Get("Accept"))
+	return resource
+}
+
+// NewServer starts a fake server with an empty model. Close it when done.
+func NewServer() *Server {
+	s := &Server{Token: DefaultToken, model: NewModel()}
+	s.routes = s.buildRoutes()
+	s.http = httptest.NewServer(s)
+	s.URL = s.http.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.http.Close()
+}
+
+// NewClient returns a client of the server.
+func (s *Server) NewClient() *client.Client {
+	return client.NewClient(s.URL, s.Token)
+}
+
+// Update calls f with the model locked, to seed or inspect the state of the server.
+func (s *Server) Update(f func(m *Model)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.model)
+}
+
+// Reset empties the model and clears failures, latency and recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.model = NewModel()
+	s.failures = nil
+	s.latency = 0
+	s.requests = nil
+}
+
+// Fail makes requests matching the failure fail. Failures are checked in the order added.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &f)
+}
+
+// ClearFailures serves all requests again.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = nil
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ResetRequests forgets the requests received.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// ServeHTTP records the request, applies latency and failures, checks the token and the API
+// version, and serves the route.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	rec := Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: body,
+		Time: time.Now()}
+	sw := &statusWriter{ResponseWriter: w}
+	defer func() {
+		rec.Status = sw.status
+		s.mu.Lock()
+		s.requests = append(s.requests, rec)
+		s.mu.Unlock()
+	}()
+
+	s.mu.Lock()
+	latency := s.latency
+	failure := s.failure(r)
+	s.mu.Unlock()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failure != nil {
+		s.fail(sw, failure)
+		return
+	}
+	user, ok := s.user(r)
+	if !ok {
+		reply(sw, http.StatusUnauthorized, "invalid or missing bearer token")
+		return
+	}
+	rec.User = user
+	r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+	if r.Method == http.MethodGet {
+		s.serveGet(sw, r)
+		return
+	}
+	s.serve(sw, r)
+}
+
+// userKey is the context key of the user of a request.
+type userKey struct{}
+
+// user returns the user of the bearer token of a request, or false if the token is refused.
+func (s *Server) user(r *http.Request) (string, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if u, ok := s.Users[token]; ok {
+		return u, true
+	}
+	if s.Token == "" || token == s.Token {
+		return DefaultUser, true
+	}
+	return "", false
+}
+
+// requestUser returns the user of the bearer token of a request being served.
+func requestUser(r *http.Request) string {
+	user, _ := r.Context().Value(userKey{}).(string)
+	return user
+}
+
+// serveGet answers a GET with the ETag of its body, or with 304 Not Modified if the client
+// already has it.
+func (s *Server) serveGet(w http.ResponseWriter, r *http.Request) {
+	rec := httptest.NewRecorder()
+	s.serve(rec, r)
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	if rec.Code == http.StatusOK {
+		etag := fmt.Sprintf(`"%x"`, sha1.Sum(rec.Body.Bytes()))
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
+
+// failure returns the first failure matching a request and counts it down.
+func (s *Server) failure(r *http.Request) *Failure {
+	for i, f := range s.failures {
+		if !f.matches(r) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i:i], s.failures[i+1:]...)
+			}
+		}
+		c := *f
+		return &c
+	}
+	return nil
+}
+
+// fail answers a request with an injected failure.
+func (s *Server) fail(w *statusWriter, f *Failure) {
+	if f.Drop {
+		if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+	status := f.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	if f.Body != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(f.Body))
+		return
+	}
+	message := f.Message
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	reply(w, status, message)
+}
+
+// statusWriter records the status of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
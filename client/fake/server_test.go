@@ -0,0 +1,127 @@
+package fake
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/composer22/k8ctl/client"
+)
+
+// statusOf returns the HTTP status of an error of the client, or 0.
+func statusOf(err error) int {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func TestDeployAndList(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	cl := s.NewClient()
+	if _, err := cl.Deploy("myapp", "k8-1.0.0-1", "dev", "first"); err != nil {
+		t.Fatalf("Deploy: %s", err)
+	}
+	releases, err := cl.Releases("dev")
+	if err != nil {
+		t.Fatalf("Releases: %s", err)
+	}
+	if len(releases) != 1 || releases[0].Name != "myapp-dev" || releases[0].VersionTag != "k8-1.0.0-1" ||
+		releases[0].Status != client.ReleaseDeployed {
+		t.Errorf("Releases = %+v, want myapp-dev deployed with k8-1.0.0-1", releases)
+	}
+}
+
+func TestToken(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Users = map[string]string{"reviewer-token": "reviewer"}
+	if _, err := client.NewClient(s.URL, "wrong").Releases(""); statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("wrong token: err = %v, want 401", err)
+	}
+	if _, err := client.NewClient(s.URL, "reviewer-token").Releases(""); err != nil {
+		t.Errorf("token of Users: %s", err)
+	}
+	reqs := s.Requests()
+	if len(reqs) != 2 || reqs[0].User != "" || reqs[1].User != "reviewer" {
+		t.Errorf("users of the requests = %+v, want none then reviewer", reqs)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Update(func(m *Model) { m.Deploy("dry-run", "dev", "k8-1.0.0-1", "") })
+	req, _ := http.NewRequest(http.MethodGet, s.URL+"/releases/dry-run", nil)
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /releases/dry-run = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Fail(Failure{Method: http.MethodGet, Path: "/releases", Status: http.StatusServiceUnavailable, Times: 1})
+	cl := s.NewClient()
+	if _, err := cl.Releases(""); statusOf(err) != http.StatusServiceUnavailable {
+		t.Errorf("first request: err = %v, want 503", err)
+	}
+	if _, err := cl.Releases(""); err != nil {
+		t.Errorf("second request: %s", err)
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Users = map[string]string{"reviewer-token": "reviewer"}
+	s.Update(func(m *Model) { m.ApprovalNamespaces = []string{"prod"} })
+	requester, reviewer := s.NewClient(), client.NewClient(s.URL, "reviewer-token")
+
+	d, err := requester.Discover()
+	if err != nil {
+		t.Fatalf("Discover: %s", err)
+	}
+	if !d.RequiresApproval("prod") || d.RequiresApproval("dev") {
+		t.Errorf("ApprovalNamespaces = %v, want prod", d.ApprovalNamespaces)
+	}
+	if _, err := requester.Deploy("myapp", "k8-1.0.0-1", "prod", ""); statusOf(err) != http.StatusForbidden {
+		t.Errorf("direct deploy to prod: err = %v, want 403", err)
+	}
+
+	resp, err := requester.RequestApproval("myapp", "k8-1.0.0-1", "prod", "")
+	if err != nil {
+		t.Fatalf("RequestApproval: %s", err)
+	}
+	var a client.Approval
+	if err := resp.Decode(&a); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if a.Requester != DefaultUser {
+		t.Errorf("Requester = %q, want %q", a.Requester, DefaultUser)
+	}
+	if _, err := requester.Approve(a.ID); statusOf(err) != http.StatusForbidden {
+		t.Errorf("approval by the requester: err = %v, want 403", err)
+	}
+	if _, err := reviewer.Approve(a.ID); err != nil {
+		t.Fatalf("Approve: %s", err)
+	}
+	got, err := requester.Approval(a.ID)
+	if err != nil {
+		t.Fatalf("Approval: %s", err)
+	}
+	if got.Status != client.ApprovalApproved || got.Reviewer != "reviewer" {
+		t.Errorf("approval = %s by %q, want approved by reviewer", got.Status, got.Reviewer)
+	}
+	if rel, err := requester.FindRelease("myapp", "prod"); err != nil || rel.VersionTag != "k8-1.0.0-1" {
+		t.Errorf("FindRelease = %+v, %v, want k8-1.0.0-1 deployed", rel, err)
+	}
+}
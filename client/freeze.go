@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Freeze is a period during which deploys, rollbacks, deletes and restarts are refused in a
+// namespace. A namespace of "*" freezes the whole cluster.
+type Freeze struct {
+	Namespace string    `json:"namespace"`        // The namespace frozen, or * for all.
+	Until     time.Time `json:"until"`            // When the freeze ends.
+	Reason    string    `json:"reason"`           // Why changes are frozen.
+	User      string    `json:"user,omitempty"`   // Who set the freeze.
+	Source    string    `json:"source,omitempty"` // server or the name of a scheduled window.
+}
+
+// Applies returns true if the freeze covers the namespace at time t.
+func (f *Freeze) Applies(namespace string, t time.Time) bool {
+	return (f.Namespace == "*" || f.Namespace == namespace) && t.Before(f.Until)
+}
+
+// FreezeRequest is the payload to set a freeze on the server.
+type FreezeRequest struct {
+	Namespace string    `json:"namespace"` // The namespace to freeze, or * for all.
+	Until     time.Time `json:"until"`     // When the freeze ends.
+	Reason    string    `json:"reason"`    // Why changes are frozen.
+	User      string    `json:"user"`      // Who set the freeze.
+}
+
+// FreezeWindow is a recurring freeze scheduled in the local config. It starts whenever the
+// cron expression fires in the time zone of the window and lasts for the duration.
+type FreezeWindow struct {
+	Name       string        `mapstructure:"name"`       // A name for the window, ex: weekend.
+	Namespaces []string      `mapstructure:"namespaces"` // Namespaces frozen, * for all.
+	Cron       string        `mapstructure:"cron"`       // When the window starts, ex: 0 18 * * 5.
+	Timezone   string        `mapstructure:"timezone"`   // The zone of the cron expression (default: UTC).
+	Duration   time.Duration `mapstructure:"duration"`   // How long the window lasts, ex: 62h.
+	Reason     string        `mapstructure:"reason"`     // Why changes are frozen.
+}
+
+// Active returns the freezes of the window in effect at time t. The window is evaluated in its
+// time zone, so that everyone agrees on when it starts whatever their local zone.
+func (w *FreezeWindow) Active(t time.Time) ([]Freeze, error) {
+	s, err := ParseCron(w.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("freeze window %s: %s", w.Name, err)
+	}
+	zone := w.Timezone
+	if zone == "" {
+		zone = "UTC"
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("freeze window %s: invalid timezone %q: %s", w.Name, w.Timezone, err)
+	}
+	t = t.In(loc)
+	start, ok := s.LastFire(t, w.Duration)
+	if !ok || !t.Before(start.Add(w.Duration)) {
+		return nil, nil
+	}
+	var result []Freeze
+	for _, ns := range w.Namespaces {
+		result = append(result, Freeze{
+			Namespace: ns,
+			Until:     start.Add(w.Duration),
+			Reason:    w.Reason,
+			Source:    w.Name,
+		})
+	}
+	return result, nil
+}
+
+// Freezes returns the freezes set on the server, for a namespace or all if empty.
+func (c *Client) Freezes(namespace string) ([]Freeze, error) {
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteFreezes), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("n", namespace)
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	resp, err := c.sendRequest(req, "freezes", httpRouteFreezesVersion)
+	if err != nil {
+		return nil, err
+	}
+	var freezes []Freeze
+	if err := resp.Decode(&freezes); err != nil {
+		return nil, fmt.Errorf("decode freezes: %w", err)
+	}
+	for i := range freezes {
+		freezes[i].Source = "server"
+	}
+	return freezes, nil
+}
+
+// SetFreeze freezes a namespace on the server until a time.
+func (c *Client) SetFreeze(namespace string, until time.Time, reason string, user string) (*Response, error) {
+	// Create the payload.
+	fr := &FreezeRequest{
+		Namespace: namespace,
+		Until:     until,
+		Reason:    reason,
+		User:      user,
+	}
+	payload, err := json.Marshal(fr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request.
+	req, err := http.NewRequest(httpPost, fmt.Sprintf("%s%s", c.Url, httpRouteFreezes), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	return c.sendRequest(req, "freezes", httpRouteFreezesVersion)
+}
+
+// ClearFreeze removes the freeze of a namespace on the server.
+func (c *Client) ClearFreeze(namespace string) (*Response, error) {
+	req, err := http.NewRequest(httpDelete, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteFreeze, namespace)), nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.sendRequest(req, "freezes", httpRouteFreezesVersion)
+}
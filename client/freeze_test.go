@@ -0,0 +1,41 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreezeWindowTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone database: %s", err)
+	}
+	// Friday 2026-10-16 at 19:00 in New York is 23:00 UTC.
+	at := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC).In(time.FixedZone("PDT", -7*3600))
+	tests := []struct {
+		timezone string
+		active   bool
+	}{
+		{"America/New_York", true},
+		{"", false}, // 18:00 to 22:00 UTC.
+		{"UTC", false},
+	}
+	for _, tt := range tests {
+		w := FreezeWindow{Name: "evening", Namespaces: []string{"prod"}, Cron: "0 18 * * 5", Timezone: tt.timezone,
+			Duration: 4 * time.Hour}
+		freezes, err := w.Active(at)
+		if err != nil {
+			t.Fatalf("Active in %q: %s", tt.timezone, err)
+		}
+		if active := len(freezes) > 0; active != tt.active {
+			t.Errorf("Active in %q = %v, want active %t", tt.timezone, freezes, tt.active)
+		}
+		if tt.active && !freezes[0].Until.Equal(time.Date(2026, 10, 16, 22, 0, 0, 0, newYork)) {
+			t.Errorf("Until = %s, want 22:00 in New York", freezes[0].Until)
+		}
+	}
+	w := FreezeWindow{Name: "bad", Cron: "0 18 * * 5", Timezone: "Mars/Olympus", Duration: time.Hour}
+	if _, err := w.Active(at); err == nil {
+		t.Error("Active with an invalid timezone succeeded")
+	}
+}
@@ -0,0 +1,435 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Health verdicts.
+const (
+	HealthHealthy   = "healthy"   // Every check ran and found nothing wrong.
+	HealthUnhealthy = "unhealthy" // A check found a problem.
+	HealthUnknown   = "unknown"   // No problem was found but a check could not run or judge everything.
+)
+
+// Health checks, by the resource they look at.
+const (
+	CheckReleases    = "releases"    // Releases failed or pending.
+	CheckDeployments = "deployments" // Deployments with unavailable replicas.
+	CheckPods        = "pods"        // Pods restarting or not ready.
+	CheckJobs        = "jobs"        // Failed jobs.
+	CheckCronjobs    = "cronjobs"    // Cronjobs that have not run on schedule.
+	CheckServices    = "services"    // Services without endpoints.
+)
+
+// HealthChecks are all the checks, in the order they run.
+var HealthChecks = []string{CheckReleases, CheckDeployments, CheckPods, CheckJobs, CheckCronjobs, CheckServices}
+
+// Defaults of HealthOptions.
+const (
+	DefaultMaxRestarts = 3                // Restarts of a pod beyond which it is reported.
+	DefaultCronGrace   = 10 * time.Minute // How late a cronjob may run before it is reported.
+	cronLookback       = 32 * 24 * time.Hour
+)
+
+// HealthOptions configure CheckHealth.
+type HealthOptions struct {
+	Checks      []string         // The checks to run (optional: default is HealthChecks).
+	Skipped     []string         // Checks not run, ex: the server does not support them.
+	MaxRestarts int              // Restarts of a pod beyond which it is reported.
+	CronGrace   time.Duration    // How late a cronjob may run before it is reported.
+	Now         func() time.Time // The clock (optional).
+}
+
+// HealthProblem is something wrong found by a check.
+type HealthProblem struct {
+	Check  string `json:"check"`  // The check that found it.
+	Name   string `json:"name"`   // The release or resource.
+	Reason string `json:"reason"` // What is wrong, ex: 0/2 replicas available.
+}
+
+// HealthCheckResult is the outcome of one check.
+type HealthCheckResult struct {
+	Check    string `json:"check"`             // The check.
+	Skipped  bool   `json:"skipped,omitempty"` // It did not run, ex: the server does not support it.
+	Checked  int    `json:"checked"`           // How many releases or resources it looked at.
+	Problems int    `json:"problems"`          // How many problems it found.
+	Unknown  int    `json:"unknown,omitempty"` // How many releases or resources it could not judge.
+	Error    string `json:"error,omitempty"`   // Why it could not run.
+	Err      error  `json:"-"`                 // The error, for its exit code.
+}
+
+// HealthReport is the health of a namespace.
+type HealthReport struct {
+	Namespace string              `json:"namespace"` // The namespace checked.
+	Time      time.Time           `json:"time"`      // When it was checked.
+	Verdict   string              `json:"verdict"`   // healthy, unhealthy or unknown.
+	Checks    []HealthCheckResult `json:"checks"`    // The outcome of each check.
+	Problems  []HealthProblem     `json:"problems"`  // What is wrong.
+	Unknown   []HealthProblem     `json:"unknown"`   // What could not be judged, and why.
+}
+
+// CheckHealth checks the releases and resources of a namespace. A check that cannot run or is
+// skipped, or a resource that cannot be judged, is reported and makes the verdict unknown,
+// unless a problem was found.
+func CheckHealth(cl Interface, namespace string, opts *HealthOptions) *HealthReport {
+	if opts == nil {
+		opts = &HealthOptions{MaxRestarts: DefaultMaxRestarts, CronGrace: DefaultCronGrace}
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	checks := opts.Checks
+	if len(checks) == 0 {
+		checks = HealthChecks
+	}
+	r := &HealthReport{Namespace: namespace, Time: now(), Verdict: HealthHealthy, Problems: []HealthProblem{},
+		Unknown: []HealthProblem{}}
+	failed := false
+	for _, check := range checks {
+		if contains(opts.Skipped, check) {
+			r.Checks = append(r.Checks, HealthCheckResult{Check: check, Skipped: true})
+			failed = true
+			continue
+		}
+		var checked int
+		var problems, unknown []HealthProblem
+		var err error
+		switch check {
+		case CheckReleases:
+			checked, problems, err = checkReleases(cl, namespace)
+		case CheckDeployments:
+			checked, problems, err = checkRows(cl, namespace, check, checkDeployment)
+		case CheckPods:
+			checked, problems, err = checkRows(cl, namespace, check, func(row map[string]string) string {
+				return checkPod(row, opts.MaxRestarts)
+			})
+		case CheckJobs:
+			checked, problems, err = checkJobs(cl, namespace)
+		case CheckCronjobs:
+			checked, problems, err = checkRows(cl, namespace, check, func(row map[string]string) string {
+				return checkCronjob(row, r.Time, opts.CronGrace)
+			})
+		case CheckServices:
+			checked, problems, unknown, err = checkServices(cl, namespace)
+		default:
+			err = fmt.Errorf("unknown check %s", check)
+		}
+		result := HealthCheckResult{Check: check, Checked: checked, Problems: len(problems), Unknown: len(unknown)}
+		if err != nil {
+			result.Error, result.Err = err.Error(), err
+		}
+		failed = failed || err != nil || len(unknown) > 0
+		r.Checks = append(r.Checks, result)
+		r.Problems = append(r.Problems, problems...)
+		r.Unknown = append(r.Unknown, unknown...)
+	}
+	switch {
+	case len(r.Problems) > 0:
+		r.Verdict = HealthUnhealthy
+	case failed:
+		r.Verdict = HealthUnknown
+	}
+	return r
+}
+
+// checkReleases reports the releases failed or pending.
+func checkReleases(cl Interface, namespace string) (int, []HealthProblem, error) {
+	releases, err := cl.Releases(namespace)
+	if err != nil {
+		return 0, nil, err
+	}
+	var problems []HealthProblem
+	for _, rel := range releases {
+		if rel.Status == ReleaseFailed || strings.HasPrefix(rel.Status, "pending") {
+			problems = append(problems, HealthProblem{Check: CheckReleases, Name: rel.Name,
+				Reason: fmt.Sprintf("revision %d is %s", rel.Revision, rel.Status)})
+		}
+	}
+	return len(releases), problems, nil
+}
+
+// checkRows lists a resource kind and reports the rows for which check returns a reason.
+func checkRows(cl Interface, namespace string, kind string, check func(map[string]string) string) (int,
+	[]HealthProblem, error) {
+	rows, err := listRows(cl, kind, namespace)
+	if err != nil {
+		return 0, nil, err
+	}
+	var problems []HealthProblem
+	for _, row := range rows {
+		if reason := check(row); reason != "" {
+			problems = append(problems, HealthProblem{Check: kind, Name: row["name"], Reason: reason})
+		}
+	}
+	return len(rows), problems, nil
+}
+
+// listRows returns the list of a resource kind, keyed by lowercased column.
+func listRows(cl Interface, kind string, namespace string) ([]map[string]string, error) {
+	resp, err := cl.ResourceList(kind, namespace, "json")
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]string
+	if err := resp.Decode(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// rowNamespace returns the namespace of a list row: the namespace listed, or the namespace
+// column of the row if all namespaces were listed.
+func rowNamespace(namespace string, row map[string]string) string {
+	if namespace == "" {
+		return row["namespace"]
+	}
+	return namespace
+}
+
+// checkDeployment reports a deployment with unavailable replicas.
+func checkDeployment(row map[string]string) string {
+	ready, desired, ok := parseRatio(row["ready"])
+	if !ok {
+		return ""
+	}
+	available := ready
+	if v, err := strconv.Atoi(row["available"]); err == nil {
+		available = v
+	}
+	if available < desired {
+		return fmt.Sprintf("%d/%d replicas available", available, desired)
+	}
+	return ""
+}
+
+// podDone are the statuses of pods that ran to completion.
+var podDone = map[string]bool{"Completed": true, "Succeeded": true}
+
+// checkPod reports a pod not running, not ready or restarting.
+func checkPod(row map[string]string, maxRestarts int) string {
+	status := row["status"]
+	var reasons []string
+	if status != "" && status != "Running" && !podDone[status] {
+		reasons = append(reasons, status)
+	}
+	if ready, total, ok := parseRatio(row["ready"]); ok && ready < total && !podDone[status] {
+		reasons = append(reasons, fmt.Sprintf("%d/%d containers ready", ready, total))
+	}
+	if restarts := leadingInt(row["restarts"]); restarts > maxRestarts {
+		reasons = append(reasons, fmt.Sprintf("%d restarts", restarts))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// jobPodStatuses matches the pod statuses of a job in its describe output, ex:
+// 0 Active (0 Ready) / 0 Succeeded / 3 Failed.
+var jobPodStatuses = regexp.MustCompile(`(?i)(\d+) active(?: \([^)]*\))? / (\d+) succeeded / (\d+) failed`)
+
+// jobFailedCondition matches a Failed condition or status of a job in its describe output, ex:
+// the row "Failed  True  BackoffLimitExceeded" of the conditions.
+var jobFailedCondition = regexp.MustCompile(`(?mi)^\s*(?:(?:status|type|conditions):\s*failed|failed\s+true)\b`)
+
+// checkJobs reports the failed jobs.
+func checkJobs(cl Interface, namespace string) (int, []HealthProblem, error) {
+	rows, err := listRows(cl, CheckJobs, namespace)
+	if err != nil {
+		return 0, nil, err
+	}
+	var problems []HealthProblem
+	for _, row := range rows {
+		failed, err := jobFailed(cl, namespace, row)
+		if err != nil {
+			return len(rows), problems, err
+		}
+		if failed {
+			problems = append(problems, HealthProblem{Check: CheckJobs, Name: row["name"],
+				Reason: fmt.Sprintf("failed, %s completions", row["completions"])})
+		}
+	}
+	return len(rows), problems, nil
+}
+
+// jobFailed returns true if the job of a list row of a namespace failed, "" for all namespaces.
+// The list has no status column, so a job that has not completed is described unless its row
+// has a status field.
+func jobFailed(cl Interface, namespace string, row map[string]string) (bool, error) {
+	if done, total, ok := parseRatio(row["completions"]); ok && done >= total {
+		return false, nil
+	}
+	if status, ok := row["status"]; ok {
+		return strings.EqualFold(status, "failed"), nil
+	}
+	resp, err := cl.ResourceDescribe(CheckJobs, row["name"], rowNamespace(namespace, row))
+	if err != nil {
+		return false, err
+	}
+	return describesFailedJob(resp.Message), nil
+}
+
+// describesFailedJob returns true if the describe output of a job has a Failed condition, or
+// failed pods and none active. A job with failed pods that is still active is retrying.
+func describesFailedJob(describe string) bool {
+	if jobFailedCondition.MatchString(describe) {
+		return true
+	}
+	m := jobPodStatuses.FindStringSubmatch(describe)
+	if m == nil {
+		return false
+	}
+	active, _ := strconv.Atoi(m[1])
+	failed, _ := strconv.Atoi(m[3])
+	return active == 0 && failed > 0
+}
+
+// checkCronjob reports a cronjob that has not run since its schedule last fired, allowing for
+// grace. Ages are rounded down by the server, so the most recent run they allow is assumed.
+// Schedules are evaluated in UTC, the zone of the cronjob controller, not in the local zone.
+func checkCronjob(row map[string]string, now time.Time, grace time.Duration) string {
+	now = now.UTC()
+	if strings.EqualFold(row["suspend"], "true") {
+		return ""
+	}
+	s, err := ParseCron(row["schedule"])
+	if err != nil {
+		return "" // Ex: @hourly or a time zone, which are not supported.
+	}
+	due, ok := s.LastFire(now.Add(-grace), cronLookback)
+	if !ok {
+		return ""
+	}
+	last := row["last schedule"]
+	if last == "" || last == "<none>" {
+		if created, ok := parseAge(row["age"]); ok && now.Add(-created).Before(due) {
+			return fmt.Sprintf("never ran, due %s", due.Format(time.RFC3339))
+		}
+		return ""
+	}
+	ago, ok := parseAge(last)
+	if !ok {
+		return ""
+	}
+	if now.Add(-ago).Before(due) {
+		return fmt.Sprintf("last ran %s ago, due %s", last, due.Format(time.RFC3339))
+	}
+	return ""
+}
+
+// endpointsLine matches the endpoints of a service in its describe output.
+var endpointsLine = regexp.MustCompile(`(?mi)^\s*endpoints:\s*(.*)$`)
+
+// checkServices reports the services without endpoints. The list has no endpoints column, so
+// each service is described unless its row has an endpoints field. The services whose describe
+// output has no endpoints are returned as unknown.
+func checkServices(cl Interface, namespace string) (int, []HealthProblem, []HealthProblem, error) {
+	rows, err := listRows(cl, CheckServices, namespace)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	var problems, unknown []HealthProblem
+	for _, row := range rows {
+		if row["type"] == "ExternalName" {
+			continue
+		}
+		endpoints, ok := row["endpoints"]
+		if !ok {
+			resp, err := cl.ResourceDescribe(CheckServices, row["name"], namespace)
+			if err != nil {
+				return len(rows), problems, unknown, err
+			}
+			m := endpointsLine.FindStringSubmatch(resp.Message)
+			if m == nil {
+				unknown = append(unknown, HealthProblem{Check: CheckServices, Name: row["name"],
+					Reason: "the server does not report its endpoints"})
+				continue
+			}
+			endpoints = m[1]
+		}
+		if e := strings.TrimSpace(endpoints); e == "" || e == "<none>" {
+			problems = append(problems, HealthProblem{Check: CheckServices, Name: row["name"], Reason: "no endpoints"})
+		}
+	}
+	return len(rows), problems, unknown, nil
+}
+
+// parseRatio parses a count such as 1/2.
+func parseRatio(s string) (int, int, bool) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	a, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	b, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	return a, b, err1 == nil && err2 == nil
+}
+
+// leadingInt parses the number at the start of a field, ex: 4 (5m ago); 0 if there is none.
+func leadingInt(s string) int {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[0])
+	return n
+}
+
+// ageUnits are the units of kube ages.
+var ageUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'y': 365 * 24 * time.Hour,
+}
+
+// parseAge parses a kube age such as 45s, 5m, 3h, 2d3h or 5y.
+func parseAge(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	var d time.Duration
+	n := -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			if n < 0 {
+				n = 0
+			}
+			n = n*10 + int(c-'0')
+		case ageUnits[c] != 0 && n >= 0:
+			d += time.Duration(n) * ageUnits[c]
+			n = -1
+		default:
+			return 0, false
+		}
+	}
+	return d, n < 0
+}
+
+// Summary returns a one line verdict of the report.
+func (r *HealthReport) Summary() string {
+	switch r.Verdict {
+	case HealthUnhealthy:
+		return fmt.Sprintf("namespace %s is unhealthy: %d problems", r.Namespace, len(r.Problems))
+	case HealthUnknown:
+		for _, c := range r.Checks {
+			switch {
+			case c.Error != "":
+				return fmt.Sprintf("the health of namespace %s is unknown: the %s check could not run", r.Namespace,
+					c.Check)
+			case c.Skipped:
+				return fmt.Sprintf("the health of namespace %s is unknown: the %s check was skipped", r.Namespace,
+					c.Check)
+			}
+		}
+		return fmt.Sprintf("the health of namespace %s is unknown: %d resources could not be checked", r.Namespace,
+			len(r.Unknown))
+	}
+	return fmt.Sprintf("namespace %s is healthy", r.Namespace)
+}
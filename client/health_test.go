@@ -0,0 +1,246 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// healthStub serves lists and describes of resources for the health checks.
+type healthStub struct {
+	Interface
+	lists     map[string][]map[string]string // Rows keyed by kind.
+	describes map[string]string              // Describe output keyed by name.
+	described []string                       // The namespace/name of each describe.
+	listErr   error
+}
+
+func (s *healthStub) Releases(namespace string) ([]Release, error) {
+	return nil, nil
+}
+
+func (s *healthStub) ResourceList(kind string, namespace string, format string) (*Response, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	b, _ := json.Marshal(s.lists[kind])
+	return &Response{Status: "OK", Message: string(b)}, nil
+}
+
+func (s *healthStub) ResourceDescribe(kind string, name string, namespace string) (*Response, error) {
+	s.described = append(s.described, namespace+"/"+name)
+	return &Response{Status: "OK", Message: s.describes[name]}, nil
+}
+
+func TestCheckPod(t *testing.T) {
+	tests := []struct {
+		row  map[string]string
+		want string
+	}{
+		{map[string]string{"status": "Running", "ready": "1/1", "restarts": "0"}, ""},
+		{map[string]string{"status": "Completed", "ready": "0/1", "restarts": "0"}, ""},
+		{map[string]string{"status": "Running", "ready": "1/2", "restarts": "3"}, "1/2 containers ready"},
+		{map[string]string{"status": "Running", "ready": "1/1", "restarts": "4 (5m ago)"}, "4 restarts"},
+		{map[string]string{"status": "CrashLoopBackOff", "ready": "0/1", "restarts": "7"},
+			"CrashLoopBackOff, 0/1 containers ready, 7 restarts"},
+	}
+	for _, tt := range tests {
+		if got := checkPod(tt.row, 3); got != tt.want {
+			t.Errorf("checkPod(%v) = %q, want %q", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDeployment(t *testing.T) {
+	tests := []struct {
+		row  map[string]string
+		want string
+	}{
+		{map[string]string{"ready": "2/2", "available": "2"}, ""},
+		{map[string]string{"ready": "2/2", "available": "1"}, "1/2 replicas available"},
+		{map[string]string{"ready": "0/3"}, "0/3 replicas available"},
+		{map[string]string{"ready": "unknown"}, ""},
+	}
+	for _, tt := range tests {
+		if got := checkDeployment(tt.row); got != tt.want {
+			t.Errorf("checkDeployment(%v) = %q, want %q", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestDescribesFailedJob(t *testing.T) {
+	tests := []struct {
+		describe string
+		want     bool
+	}{
+		{"Pods Statuses: 0 Active / 0 Succeeded / 1 Failed", true},
+		{"Pods Statuses: 0 Active (0 Ready) / 0 Succeeded / 6 Failed", true},
+		{"Pods Statuses: 1 Active / 0 Succeeded / 1 Failed", false},
+		{"Pods Statuses: 1 Active (1 Ready) / 0 Succeeded / 2 Failed", false},
+		{"Pods Statuses: 0 Active / 1 Succeeded / 0 Failed", false},
+		{"Conditions:\n  Type    Status  Reason\n  Failed  True    BackoffLimitExceeded", true},
+		{"Status: Failed", true},
+		{"Name: failed-import", false},
+	}
+	for _, tt := range tests {
+		if got := describesFailedJob(tt.describe); got != tt.want {
+			t.Errorf("describesFailedJob(%q) = %t, want %t", tt.describe, got, tt.want)
+		}
+	}
+}
+
+func TestCheckJobs(t *testing.T) {
+	cl := &healthStub{
+		lists: map[string][]map[string]string{CheckJobs: {
+			{"name": "done", "completions": "1/1"},
+			{"name": "failed", "completions": "0/1"},
+			{"name": "retrying", "completions": "0/1"},
+		}},
+		describes: map[string]string{
+			"failed":   "Pods Statuses: 0 Active / 0 Succeeded / 6 Failed",
+			"retrying": "Pods Statuses: 1 Active / 0 Succeeded / 2 Failed",
+		},
+	}
+	checked, problems, err := checkJobs(cl, "dev")
+	if err != nil {
+		t.Fatalf("checkJobs: %s", err)
+	}
+	if checked != 3 || len(problems) != 1 || problems[0].Name != "failed" {
+		t.Errorf("checkJobs = %d, %v, want 3 jobs and failed reported", checked, problems)
+	}
+}
+
+func TestJobFailedNamespace(t *testing.T) {
+	cl := &healthStub{describes: map[string]string{"import": "Pods Statuses: 0 Active / 0 Succeeded / 1 Failed"}}
+	tests := []struct {
+		namespace string
+		row       map[string]string
+		want      string
+	}{
+		{"dev", map[string]string{"name": "import", "completions": "0/1"}, "dev/import"},
+		{"", map[string]string{"name": "import", "namespace": "qa", "completions": "0/1"}, "qa/import"},
+	}
+	for _, tt := range tests {
+		cl.described = nil
+		failed, err := jobFailed(cl, tt.namespace, tt.row)
+		if err != nil || !failed {
+			t.Errorf("jobFailed(%q) = %t, %v, want failed", tt.namespace, failed, err)
+		}
+		if len(cl.described) != 1 || cl.described[0] != tt.want {
+			t.Errorf("jobFailed(%q) described %v, want %s", tt.namespace, cl.described, tt.want)
+		}
+	}
+}
+
+func TestCheckCronjob(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		row  map[string]string
+		want string
+	}{
+		{map[string]string{"schedule": "0 * * * *", "last schedule": "30m", "age": "5d"}, ""},
+		{map[string]string{"schedule": "0 * * * *", "last schedule": "3h", "age": "5d"},
+			"last ran 3h ago, due 2026-10-19T12:00:00Z"},
+		{map[string]string{"schedule": "0 * * * *", "last schedule": "3h", "age": "5d", "suspend": "True"}, ""},
+		{map[string]string{"schedule": "0 * * * *", "last schedule": "<none>", "age": "2d"},
+			"never ran, due 2026-10-19T12:00:00Z"},
+		{map[string]string{"schedule": "0 * * * *", "last schedule": "<none>", "age": "5m"}, ""},
+		{map[string]string{"schedule": "@hourly", "last schedule": "3h", "age": "5d"}, ""},
+	}
+	for _, tt := range tests {
+		if got := checkCronjob(tt.row, now, DefaultCronGrace); got != tt.want {
+			t.Errorf("checkCronjob(%v) = %q, want %q", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age  string
+		want time.Duration
+		ok   bool
+	}{
+		{"45s", 45 * time.Second, true},
+		{"5m", 5 * time.Minute, true},
+		{"2d3h", 51 * time.Hour, true},
+		{"1y", 365 * 24 * time.Hour, true},
+		{"", 0, false},
+		{"5", 0, false},
+		{"m5", 0, false},
+		{"<none>", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseAge(tt.age)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseAge(%q) = %s, %t, want %s, %t", tt.age, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCheckHealthVerdicts(t *testing.T) {
+	running := map[string][]map[string]string{CheckPods: {{"name": "web", "status": "Running", "ready": "1/1"}}}
+	tests := []struct {
+		name    string
+		cl      *healthStub
+		opts    HealthOptions
+		verdict string
+	}{
+		{"healthy", &healthStub{lists: running}, HealthOptions{Checks: []string{CheckPods}}, HealthHealthy},
+		{"unhealthy", &healthStub{lists: map[string][]map[string]string{
+			CheckPods: {{"name": "web", "status": "Error"}}}},
+			HealthOptions{Checks: []string{CheckPods}}, HealthUnhealthy},
+		{"error", &healthStub{listErr: errors.New("refused")}, HealthOptions{Checks: []string{CheckPods}},
+			HealthUnknown},
+		{"skipped", &healthStub{lists: running}, HealthOptions{Checks: []string{CheckPods, CheckServices},
+			Skipped: []string{CheckServices}}, HealthUnknown},
+		{"no endpoints reported", &healthStub{lists: map[string][]map[string]string{
+			CheckServices: {{"name": "web", "type": "ClusterIP"}}}},
+			HealthOptions{Checks: []string{CheckServices}}, HealthUnknown},
+	}
+	for _, tt := range tests {
+		r := CheckHealth(tt.cl, "dev", &tt.opts)
+		if r.Verdict != tt.verdict {
+			t.Errorf("%s: verdict = %s, want %s (%s)", tt.name, r.Verdict, tt.verdict, r.Summary())
+		}
+	}
+}
+
+func TestCheckServices(t *testing.T) {
+	cl := &healthStub{
+		lists: map[string][]map[string]string{CheckServices: {
+			{"name": "web", "type": "ClusterIP"},
+			{"name": "idle", "type": "ClusterIP"},
+			{"name": "legacy", "type": "ClusterIP"},
+			{"name": "ext", "type": "ExternalName"},
+		}},
+		describes: map[string]string{
+			"web":  "Name: web\nEndpoints: 10.0.0.1:8080\n",
+			"idle": "Name: idle\nEndpoints: <none>\n",
+		},
+	}
+	checked, problems, unknown, err := checkServices(cl, "dev")
+	if err != nil {
+		t.Fatalf("checkServices: %s", err)
+	}
+	if checked != 4 || len(problems) != 1 || problems[0].Name != "idle" {
+		t.Errorf("problems = %v, want idle", problems)
+	}
+	if len(unknown) != 1 || unknown[0].Name != "legacy" {
+		t.Errorf("unknown = %v, want legacy", unknown)
+	}
+}
+
+func TestCheckHealthCronjobZone(t *testing.T) {
+	// At 15:30 UTC, 08:30 in Pacific time, a daily 03:00 job that ran 12h ago is on schedule in
+	// UTC; read in the local zone it would be due at 10:00 UTC and reported.
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC).In(time.FixedZone("PDT", -7*3600))
+	cl := &healthStub{lists: map[string][]map[string]string{CheckCronjobs: {
+		{"name": "nightly", "schedule": "0 3 * * *", "last schedule": "12h", "age": "30d"},
+	}}}
+	opts := HealthOptions{Checks: []string{CheckCronjobs}, CronGrace: DefaultCronGrace,
+		Now: func() time.Time { return now }}
+	if r := CheckHealth(cl, "dev", &opts); r.Verdict != HealthHealthy {
+		t.Errorf("verdict = %s, want healthy (%v)", r.Verdict, r.Problems)
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryEntry is a revision of a release.
+type HistoryEntry struct {
+	Revision    int       `json:"revision"`           // The revision number.
+	Updated     time.Time `json:"updated"`            // When the revision was deployed.
+	Status      string    `json:"status"`             // deployed, superseded, failed etc.
+	Chart       string    `json:"chart"`              // The chart name and version.
+	AppVersion  string    `json:"appVersion"`         // The app version of the chart.
+	Description string    `json:"description"`        // The helm description or the memo of the deploy.
+	Deployer    string    `json:"deployer,omitempty"` // Who deployed the revision, if known.
+}
+
+// IsRollback returns true if the revision was created by a rollback.
+func (h *HistoryEntry) IsRollback() bool {
+	return strings.HasPrefix(strings.ToLower(h.Description), "rollback")
+}
+
+// HistoryFilter selects history entries. Empty fields match everything.
+type HistoryFilter struct {
+	Since  time.Time // Only revisions deployed after this time.
+	Status string    // Only revisions with this status, ex: failed.
+	Limit  int       // Only the most recent revisions.
+}
+
+// Apply returns the entries matching the filter, oldest first.
+func (f *HistoryFilter) Apply(entries []HistoryEntry) []HistoryEntry {
+	var result []HistoryEntry
+	for _, h := range entries {
+		if !f.Since.IsZero() && h.Updated.Before(f.Since) {
+			continue
+		}
+		if f.Status != "" && !strings.EqualFold(h.Status, f.Status) {
+			continue
+		}
+		result = append(result, h)
+	}
+	if f.Limit > 0 && len(result) > f.Limit {
+		result = result[len(result)-f.Limit:]
+	}
+	return result
+}
+
+// HistoryStats summarizes the revisions of a release over a window.
+type HistoryStats struct {
+	Release            string    `json:"release"`            // The release name.
+	Since              time.Time `json:"since"`              // The start of the window.
+	Deploys            int       `json:"deploys"`            // Revisions in the window, including rollbacks.
+	Failures           int       `json:"failures"`           // Revisions that failed.
+	Rollbacks          int       `json:"rollbacks"`          // Revisions created by a rollback.
+	FailureRate        float64   `json:"failureRate"`        // Failures / deploys, 0 to 1.
+	DeploysPerWeek     float64   `json:"deploysPerWeek"`     // Deploy frequency.
+	MeanSecondsBetween int64     `json:"meanSecondsBetween"` // Mean time between deploys, in seconds.
+	LastDeploy         time.Time `json:"lastDeploy"`         // When the latest revision was deployed.
+	LastSuccess        time.Time `json:"lastSuccess"`        // When the latest successful revision was deployed.
+}
+
+// ReleaseHistory returns the revisions of a release, oldest first.
+func (c *Client) ReleaseHistory(release string) ([]HistoryEntry, error) {
+	resp, err := c.History(release, "json")
+	if err != nil {
+		return nil, err
+	}
+	var entries []HistoryEntry
+	if err := resp.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decode release history: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Revision < entries[j].Revision })
+	return entries, nil
+}
+
+// ComputeHistoryStats summarizes the revisions of a release deployed after since and up to now.
+func ComputeHistoryStats(release string, entries []HistoryEntry, since time.Time, now time.Time) *HistoryStats {
+	s := &HistoryStats{Release: release, Since: since}
+	var times []time.Time
+	for _, h := range entries {
+		if h.Updated.Before(since) {
+			continue
+		}
+		s.Deploys++
+		times = append(times, h.Updated)
+		if strings.EqualFold(h.Status, ReleaseFailed) {
+			s.Failures++
+		} else if h.Updated.After(s.LastSuccess) {
+			s.LastSuccess = h.Updated
+		}
+		if h.IsRollback() {
+			s.Rollbacks++
+		}
+		if h.Updated.After(s.LastDeploy) {
+			s.LastDeploy = h.Updated
+		}
+	}
+	if s.Deploys == 0 {
+		return s
+	}
+	s.FailureRate = float64(s.Failures) / float64(s.Deploys)
+	if weeks := now.Sub(since).Hours() / (24 * 7); weeks > 0 {
+		s.DeploysPerWeek = float64(s.Deploys) / weeks
+	}
+	if len(times) > 1 {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+		mean := times[len(times)-1].Sub(times[0]) / time.Duration(len(times)-1)
+		s.MeanSecondsBetween = int64(mean.Round(time.Second) / time.Second)
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+// historyAt returns a revision deployed hours after the start of 2026-10-01.
+func historyAt(revision int, hours int, status string, description string) HistoryEntry {
+	return HistoryEntry{Revision: revision, Updated: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).Add(
+		time.Duration(hours) * time.Hour), Status: status, Description: description}
+}
+
+func TestComputeHistoryStats(t *testing.T) {
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := since.Add(14 * 24 * time.Hour)
+	tests := []struct {
+		name    string
+		entries []HistoryEntry
+		want    HistoryStats
+	}{
+		{"empty", nil, HistoryStats{}},
+		{"single", []HistoryEntry{historyAt(1, 10, ReleaseDeployed, "Install complete")},
+			HistoryStats{Deploys: 1, DeploysPerWeek: 0.5, LastDeploy: historyAt(0, 10, "", "").Updated,
+				LastSuccess: historyAt(0, 10, "", "").Updated}},
+		{"window boundary", []HistoryEntry{
+			historyAt(1, -1, ReleaseFailed, "before the window"),
+			historyAt(2, 0, "superseded", "at the start of the window"),
+			historyAt(3, 24, ReleaseFailed, "Upgrade failed"),
+			historyAt(4, 48, ReleaseDeployed, "Rollback to 2"),
+		}, HistoryStats{Deploys: 3, Failures: 1, Rollbacks: 1, FailureRate: 1.0 / 3, DeploysPerWeek: 1.5,
+			MeanSecondsBetween: 24 * 3600, LastDeploy: historyAt(0, 48, "", "").Updated,
+			LastSuccess: historyAt(0, 48, "", "").Updated}},
+		{"all failed", []HistoryEntry{
+			historyAt(1, 1, ReleaseFailed, ""),
+			historyAt(2, 2, ReleaseFailed, ""),
+		}, HistoryStats{Deploys: 2, Failures: 2, FailureRate: 1, DeploysPerWeek: 1, MeanSecondsBetween: 3600,
+			LastDeploy: historyAt(0, 2, "", "").Updated}},
+	}
+	for _, tt := range tests {
+		got := ComputeHistoryStats("myapp-dev", tt.entries, since, now)
+		tt.want.Release, tt.want.Since = "myapp-dev", since
+		if *got != tt.want {
+			t.Errorf("%s: stats = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestHistoryFilterApply(t *testing.T) {
+	entries := []HistoryEntry{
+		historyAt(1, 0, "superseded", ""),
+		historyAt(2, 24, ReleaseFailed, ""),
+		historyAt(3, 48, "superseded", ""),
+		historyAt(4, 72, ReleaseDeployed, ""),
+	}
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   []int
+	}{
+		{"all", HistoryFilter{}, []int{1, 2, 3, 4}},
+		{"since", HistoryFilter{Since: entries[1].Updated}, []int{2, 3, 4}},
+		{"status", HistoryFilter{Status: "FAILED"}, []int{2}},
+		{"limit", HistoryFilter{Limit: 2}, []int{3, 4}},
+		{"limit above", HistoryFilter{Limit: 10}, []int{1, 2, 3, 4}},
+		{"status and limit", HistoryFilter{Status: "superseded", Limit: 1}, []int{3}},
+		{"none", HistoryFilter{Since: entries[3].Updated.Add(time.Hour)}, nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, h := range tt.filter.Apply(entries) {
+			got = append(got, h.Revision)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: revisions = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: revisions = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import "time"
+
+// Interface is the set of operations of a k8ctl-server. Client implements it over HTTP; tests
+// and programs embedding the commands may provide another backend, ex: a mock or a backend
+// talking to a local cluster directly.
+type Interface interface {
+	// Version returns the version of the client.
+	Version() string
+
+	// Helm related.
+	DeleteWithMemo(release string, memo string) (*Response, error)
+	Deploy(name string, versionTag string, namespace string, memo string) (*Response, error)
+	DeployDryRun(name string, versionTag string, namespace string) (*DryRun, error)
+	FindRelease(name string, namespace string) (*Release, error)
+	History(release string, format string) (*Response, error)
+	List(namespace string, format string) (*Response, error)
+	ReleaseContent(release string, content string, revision int) (string, error)
+	ReleaseHistory(release string) ([]HistoryEntry, error)
+	Releases(namespace string) ([]Release, error)
+	RollbackWithMemo(release string, revision string, memo string) (*Response, error)
+	Status(release string, format string) (*Response, error)
+	WaitForRelease(name string, namespace string, tag string, timeout time.Duration) (*Release, error)
+
+	// Rollouts.
+	AbortRollout(name string, namespace string, memo string) (*Response, error)
+	PromoteRollout(name string, namespace string, memo string) (*Response, error)
+	RolloutStatus(name string, namespace string) (*Rollout, error)
+	SetRolloutWeight(name string, namespace string, weight int, memo string) (*Response, error)
+	StartRollout(name string, versionTag string, namespace string, strategy string, weight int,
+		memo string) (*Response, error)
+	WaitForRollout(name string, namespace string, weight int, timeout time.Duration) (*Rollout, error)
+	WatchRollout(name string, namespace string, d time.Duration) (*Rollout, error)
+
+	// Kube related.
+	ResourceDescribe(kind string, name string, namespace string) (*Response, error)
+	ResourceList(kind string, namespace string, format string) (*Response, error)
+	ResourceRestart(kind string, name string, namespace string, memo string) (*Response, error)
+	Secret(name string, namespace string, reveal string) (*Response, error)
+	TopNamespace(namespace string) (*NamespaceMetrics, error)
+	TopPods(namespace string) ([]PodMetrics, error)
+
+	// Approvals.
+	Approval(id string) (*Approval, error)
+	Approvals(namespace string, status string) ([]Approval, error)
+	Approve(id string) (*Response, error)
+	Reject(id string, reason string) (*Response, error)
+	RequestApproval(name string, versionTag string, namespace string, memo string) (*Response, error)
+	WaitForApproval(id string, timeout time.Duration) (*Approval, error)
+
+	// Freezes.
+	ClearFreeze(namespace string) (*Response, error)
+	Freezes(namespace string) ([]Freeze, error)
+	SetFreeze(namespace string, until time.Time, reason string, user string) (*Response, error)
+
+	// Other.
+	Discover() (*Discovery, error)
+	Guide() (*Response, error)
+	ServerVersion() (string, error)
+}
+
+// Client is the HTTP implementation of Interface.
+var _ Interface = (*Client)(nil)
@@ -0,0 +1,275 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Actions of a resource change.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// ManifestResource is a kube resource of a rendered manifest.
+type ManifestResource struct {
+	Kind       string          // The kind, ex: Deployment.
+	Name       string          // The metadata name.
+	Replicas   string          // The replicas of a workload, if set.
+	Containers []ContainerSpec // The containers of a workload.
+	Text       string          // The YAML document of the resource.
+}
+
+// Key returns the kind and name of the resource, ex: Deployment/myapp.
+func (r *ManifestResource) Key() string {
+	return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+}
+
+// ContainerSpec is the part of a container compared between manifests.
+type ContainerSpec struct {
+	Name     string            // The container name.
+	Image    string            // The image and tag.
+	Env      map[string]string // Environment variables; references are shown as (from ...).
+	Requests map[string]string // Resource requests, ex: cpu: 100m.
+	Limits   map[string]string // Resource limits.
+}
+
+// ResourceChange is the difference of a resource between two manifests.
+type ResourceChange struct {
+	Kind    string   `json:"kind" yaml:"kind"`                           // The kind, ex: Deployment.
+	Name    string   `json:"name" yaml:"name"`                           // The metadata name.
+	Action  string   `json:"action" yaml:"action"`                       // added, removed or changed.
+	Changes []string `json:"changes,omitempty" yaml:"changes,omitempty"` // Changes of images, env, replicas and resources.
+	Diff    string   `json:"diff,omitempty" yaml:"diff,omitempty"`       // Unified diff of the YAML of the resource.
+}
+
+// ParseManifest splits a rendered multi-document manifest into its resources.
+func ParseManifest(text string) ([]ManifestResource, error) {
+	var result []ManifestResource
+	for _, doc := range splitDocuments(text) {
+		var m map[interface{}]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %s", err)
+		}
+		if m == nil {
+			continue
+		}
+		r := ManifestResource{
+			Kind: yamlString(m, "kind"),
+			Name: yamlString(yamlMap(m, "metadata"), "name"),
+			Text: doc,
+		}
+		spec := yamlMap(m, "spec")
+		if v, ok := spec["replicas"]; ok {
+			r.Replicas = fmt.Sprint(v)
+		}
+		var pod map[interface{}]interface{}
+		switch r.Kind {
+		case "Pod":
+			pod = spec
+		case "CronJob":
+			pod = yamlMap(yamlMap(yamlMap(yamlMap(spec, "jobTemplate"), "spec"), "template"), "spec")
+		default:
+			pod = yamlMap(yamlMap(spec, "template"), "spec")
+		}
+		for _, key := range []string{"initContainers", "containers"} {
+			list, _ := pod[key].([]interface{})
+			for _, c := range list {
+				if cm, ok := c.(map[interface{}]interface{}); ok {
+					r.Containers = append(r.Containers, containerSpec(cm))
+				}
+			}
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// DiffManifests compares the resources of two manifests. Resources are matched by kind and name.
+func DiffManifests(current string, planned string) ([]ResourceChange, error) {
+	a, err := ParseManifest(current)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ParseManifest(planned)
+	if err != nil {
+		return nil, err
+	}
+	before := map[string]*ManifestResource{}
+	var keys []string
+	for i := range a {
+		before[a[i].Key()] = &a[i]
+		keys = append(keys, a[i].Key())
+	}
+	after := map[string]*ManifestResource{}
+	for i := range b {
+		after[b[i].Key()] = &b[i]
+		if _, ok := before[b[i].Key()]; !ok {
+			keys = append(keys, b[i].Key())
+		}
+	}
+	sort.Strings(keys)
+
+	var result []ResourceChange
+	for _, key := range keys {
+		x, y := before[key], after[key]
+		switch {
+		case y == nil:
+			result = append(result, ResourceChange{Kind: x.Kind, Name: x.Name, Action: ChangeRemoved,
+				Diff: UnifiedDiff(x.Text, "", key, "/dev/null")})
+		case x == nil:
+			result = append(result, ResourceChange{Kind: y.Kind, Name: y.Name, Action: ChangeAdded,
+				Changes: resourceChanges(&ManifestResource{}, y), Diff: UnifiedDiff("", y.Text, "/dev/null", key)})
+		case x.Text != y.Text:
+			result = append(result, ResourceChange{Kind: y.Kind, Name: y.Name, Action: ChangeChanged,
+				Changes: resourceChanges(x, y), Diff: UnifiedDiff(x.Text, y.Text, key, key)})
+		}
+	}
+	return result, nil
+}
+
+// resourceChanges describes the changes of replicas, images, env and resources of a workload.
+func resourceChanges(x *ManifestResource, y *ManifestResource) []string {
+	var changes []string
+	if x.Replicas != y.Replicas {
+		changes = append(changes, describeChange("replicas", x.Replicas, y.Replicas))
+	}
+	containers := map[string]ContainerSpec{}
+	for _, c := range x.Containers {
+		containers[c.Name] = c
+	}
+	for _, c := range y.Containers {
+		old, ok := containers[c.Name]
+		delete(containers, c.Name)
+		if !ok {
+			changes = append(changes, fmt.Sprintf("container %s: added with image %s", c.Name, c.Image))
+			continue
+		}
+		prefix := fmt.Sprintf("container %s", c.Name)
+		if old.Image != c.Image {
+			changes = append(changes, describeChange(prefix+" image", old.Image, c.Image))
+		}
+		changes = append(changes, mapChanges(prefix+" env", old.Env, c.Env)...)
+		changes = append(changes, mapChanges(prefix+" requests", old.Requests, c.Requests)...)
+		changes = append(changes, mapChanges(prefix+" limits", old.Limits, c.Limits)...)
+	}
+	var removed []string
+	for name := range containers {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		changes = append(changes, fmt.Sprintf("container %s: removed", name))
+	}
+	return changes
+}
+
+// mapChanges describes the changes of the keys of two maps.
+func mapChanges(prefix string, a map[string]string, b map[string]string) []string {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	var changes []string
+	for _, k := range sorted {
+		if a[k] != b[k] {
+			changes = append(changes, describeChange(fmt.Sprintf("%s %s", prefix, k), a[k], b[k]))
+		}
+	}
+	return changes
+}
+
+// describeChange describes a field changing from a to b.
+func describeChange(field string, a string, b string) string {
+	switch {
+	case a == "":
+		return fmt.Sprintf("%s: added %s", field, b)
+	case b == "":
+		return fmt.Sprintf("%s: removed %s", field, a)
+	}
+	return fmt.Sprintf("%s: %s -> %s", field, a, b)
+}
+
+// containerSpec reads the compared fields of a container.
+func containerSpec(m map[interface{}]interface{}) ContainerSpec {
+	c := ContainerSpec{
+		Name:     yamlString(m, "name"),
+		Image:    yamlString(m, "image"),
+		Env:      map[string]string{},
+		Requests: map[string]string{},
+		Limits:   map[string]string{},
+	}
+	env, _ := m["env"].([]interface{})
+	for _, e := range env {
+		em, ok := e.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		if from, ok := em["valueFrom"]; ok {
+			b, _ := yaml.Marshal(from)
+			c.Env[yamlString(em, "name")] = fmt.Sprintf("(from %s)", strings.TrimSpace(strings.Replace(string(b), "\n", " ", -1)))
+			continue
+		}
+		c.Env[yamlString(em, "name")] = yamlString(em, "value")
+	}
+	resources := yamlMap(m, "resources")
+	for k, v := range yamlMap(resources, "requests") {
+		c.Requests[fmt.Sprint(k)] = fmt.Sprint(v)
+	}
+	for k, v := range yamlMap(resources, "limits") {
+		c.Limits[fmt.Sprint(k)] = fmt.Sprint(v)
+	}
+	return c
+}
+
+// documentSeparator matches the line separating the documents of a YAML text.
+var documentSeparator = regexp.MustCompile(`^---\s*$`)
+
+// splitDocuments splits a multi-document YAML text on its --- separators.
+func splitDocuments(text string) []string {
+	var docs []string
+	var doc []string
+	flush := func() {
+		if s := strings.TrimSpace(strings.Join(doc, "\n")); s != "" {
+			docs = append(docs, s+"\n")
+		}
+		doc = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if documentSeparator.MatchString(line) {
+			flush()
+			continue
+		}
+		doc = append(doc, line)
+	}
+	flush()
+	return docs
+}
+
+// yamlMap returns the map under a key of a decoded YAML map, or nil.
+func yamlMap(m map[interface{}]interface{}, key string) map[interface{}]interface{} {
+	v, _ := m[key].(map[interface{}]interface{})
+	return v
+}
+
+// yamlString returns the value under a key of a decoded YAML map as a string.
+func yamlString(m map[interface{}]interface{}, key string) string {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
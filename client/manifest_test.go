@@ -0,0 +1,26 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	text := `---
+kind: ConfigMap
+data:
+  key: |-
+-----BEGIN KEY-----
+---   
+kind: Service
+
+---
+`
+	want := []string{
+		"kind: ConfigMap\ndata:\n  key: |-\n-----BEGIN KEY-----\n",
+		"kind: Service\n",
+	}
+	if got := splitDocuments(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitDocuments = %q, want %q", got, want)
+	}
+}
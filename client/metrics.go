@@ -0,0 +1,113 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ContainerMetrics is the current resource usage of a single container along with
+// its requests and limits. CPU values are in millicores, memory values in bytes.
+type ContainerMetrics struct {
+	Name          string `json:"name"`          // The container name.
+	CPU           int64  `json:"cpu"`           // Current CPU usage.
+	Memory        int64  `json:"memory"`        // Current memory usage.
+	CPURequest    int64  `json:"cpuRequest"`    // CPU requested by the container spec.
+	CPULimit      int64  `json:"cpuLimit"`      // CPU limit of the container spec.
+	MemoryRequest int64  `json:"memoryRequest"` // Memory requested by the container spec.
+	MemoryLimit   int64  `json:"memoryLimit"`   // Memory limit of the container spec.
+}
+
+// PodMetrics is the current resource usage of a pod and its containers.
+type PodMetrics struct {
+	Name       string             `json:"name"`       // The pod name.
+	Namespace  string             `json:"namespace"`  // The namespace of the pod.
+	Containers []ContainerMetrics `json:"containers"` // Usage per container.
+}
+
+// Total returns the usage, requests and limits of the pod summed over its containers.
+func (p *PodMetrics) Total() ContainerMetrics {
+	t := ContainerMetrics{Name: p.Name}
+	for _, c := range p.Containers {
+		t.CPU += c.CPU
+		t.Memory += c.Memory
+		t.CPURequest += c.CPURequest
+		t.CPULimit += c.CPULimit
+		t.MemoryRequest += c.MemoryRequest
+		t.MemoryLimit += c.MemoryLimit
+	}
+	return t
+}
+
+// NamespaceMetrics is the total resource usage of all pods in a namespace.
+type NamespaceMetrics struct {
+	Namespace     string `json:"namespace"`     // The namespace name.
+	Pods          int    `json:"pods"`          // Number of pods reporting metrics.
+	CPU           int64  `json:"cpu"`           // Current CPU usage.
+	Memory        int64  `json:"memory"`        // Current memory usage.
+	CPURequest    int64  `json:"cpuRequest"`    // Sum of CPU requests.
+	CPULimit      int64  `json:"cpuLimit"`      // Sum of CPU limits.
+	MemoryRequest int64  `json:"memoryRequest"` // Sum of memory requests.
+	MemoryLimit   int64  `json:"memoryLimit"`   // Sum of memory limits.
+}
+
+// TopPods returns the current resource usage of the pods in a namespace.
+func (c *Client) TopPods(namespace string) ([]PodMetrics, error) {
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, httpRouteMetricsPods), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("n", namespace)
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	resp, err := c.sendRequest(req, "metrics", httpRouteMetricsVersion)
+	if err != nil {
+		return nil, err
+	}
+	var pods []PodMetrics
+	if err := resp.Decode(&pods); err != nil {
+		return nil, fmt.Errorf("decode pod metrics: %w", err)
+	}
+	return pods, nil
+}
+
+// TopNamespace returns the total resource usage of a namespace.
+func (c *Client) TopNamespace(namespace string) (*NamespaceMetrics, error) {
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteMetricsNamespace, namespace)), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	resp, err := c.sendRequest(req, "metrics", httpRouteMetricsVersion)
+	if err != nil {
+		return nil, err
+	}
+	var ns NamespaceMetrics
+	if err := resp.Decode(&ns); err != nil {
+		return nil, fmt.Errorf("decode namespace metrics: %w", err)
+	}
+	return &ns, nil
+}
+
+// FormatCPU renders millicores the way kubectl does, ex: 250m.
+func FormatCPU(millicores int64) string {
+	if millicores == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dm", millicores)
+}
+
+// FormatMemory renders bytes in binary units the way kubectl does, ex: 128Mi.
+func FormatMemory(bytes int64) string {
+	switch {
+	case bytes == 0:
+		return "-"
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%dMi", bytes>>20)
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%dKi", bytes>>10)
+	}
+	return fmt.Sprintf("%d", bytes)
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTopPodsDecodeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"OK","message":"metrics-server unavailable"}`)
+	}))
+	defer srv.Close()
+	cl := NewClient(srv.URL, "token")
+
+	_, err := cl.TopPods("dev")
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("TopPods error = %v, want a JSON syntax error", err)
+	}
+	if !strings.HasPrefix(err.Error(), "decode pod metrics: ") || strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("TopPods error = %q, want the decode error without the body", err)
+	}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// Notification sink types.
+const (
+	SinkWebhook = "webhook" // Generic webhook; the notification is posted as JSON.
+	SinkSlack   = "slack"   // Slack compatible incoming webhook.
+	SinkTeams   = "teams"   // Microsoft Teams compatible incoming webhook.
+)
+
+// defaultTemplate is the message used when a sink has no template.
+const defaultTemplate = `{{.User}} ran {{.Event}}{{if .Release}} of {{.Release}}{{end}}{{if .Tag}} {{.Tag}}{{end}} ` +
+	`on {{.Cluster}}{{if .Namespace}}/{{.Namespace}}{{end}}: {{.Result}} in {{.Duration}}` +
+	`{{if .Error}} ({{.Error}}){{end}}{{if .Memo}} - {{.Memo}}{{end}}`
+
+// defaultEvents are the events sent to a sink that does not list any.
+var defaultEvents = []string{"deploy", "promote", "rollback", "delete", "restart"}
+
+// Notification is the outcome of a command sent to the sinks.
+type Notification struct {
+	Event     string `json:"event"`               // deploy, rollback, delete, restart etc.
+	Cluster   string `json:"cluster"`             // The cluster of the command.
+	Namespace string `json:"namespace,omitempty"` // The namespace, if known.
+	Release   string `json:"release,omitempty"`   // The release or resource name.
+	Tag       string `json:"tag,omitempty"`       // The version tag deployed.
+	User      string `json:"user"`                // The local user running the command.
+	Memo      string `json:"memo,omitempty"`      // The memo of the command.
+	Result    string `json:"result"`              // success or failure.
+	Error     string `json:"error,omitempty"`     // The error of a failure.
+	Duration  string `json:"duration"`            // How long the command took, ex: 1.5s.
+	RequestID string `json:"requestID,omitempty"` // The X-Request-ID sent to the server.
+	Text      string `json:"text,omitempty"`      // The rendered message.
+}
+
+// Sink is a destination for notifications, configured under notifications in the config.
+type Sink struct {
+	Name     string   `mapstructure:"name"`     // A name for the sink.
+	Type     string   `mapstructure:"type"`     // webhook, slack or teams.
+	URL      string   `mapstructure:"url"`      // The webhook URL.
+	Events   []string `mapstructure:"events"`   // Events to send (optional: default is deploys, rollbacks, deletes and restarts).
+	Template string   `mapstructure:"template"` // A text/template for the message (optional).
+}
+
+// Wants returns true if the sink should receive the event.
+func (s *Sink) Wants(event string) bool {
+	events := s.Events
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns the message of a notification using the template of the sink.
+func (s *Sink) Render(n *Notification) (string, error) {
+	text := s.Template
+	if text == "" {
+		text = defaultTemplate
+	}
+	t, err := template.New(s.Name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("notification %s: %s", s.Name, err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, n); err != nil {
+		return "", fmt.Errorf("notification %s: %s", s.Name, err)
+	}
+	return b.String(), nil
+}
+
+// Send renders a notification and posts it to the sink in the format of its type.
+func (s *Sink) Send(n *Notification) error {
+	text, err := s.Render(n)
+	if err != nil {
+		return err
+	}
+	var payload interface{}
+	switch s.Type {
+	case SinkSlack:
+		payload = map[string]string{"text": text}
+	case SinkTeams:
+		color := "2EB886"
+		if n.Result != AuditSuccess {
+			color = "D00000"
+		}
+		payload = map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    fmt.Sprintf("%s %s %s", applicationName, n.Event, n.Result),
+			"themeColor": color,
+			"title":      fmt.Sprintf("%s %s: %s", applicationName, n.Event, n.Result),
+			"text":       text,
+		}
+	case SinkWebhook, "":
+		m := *n
+		m.Text = text
+		payload = &m
+	default:
+		return fmt.Errorf("notification %s: unknown type %q (webhook|slack|teams)", s.Name, s.Type)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	cl := &http.Client{Timeout: notifyTimeout}
+	resp, err := cl.Post(s.URL, "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return fmt.Errorf("notification %s: %s", s.Name, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification %s: %s", s.Name, resp.Status)
+	}
+	return nil
+}
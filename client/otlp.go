@@ -0,0 +1,217 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	otlpTracesPath    = "/v1/traces"    // The OTLP/HTTP route of traces.
+	otlpExportTimeout = 5 * time.Second // How long an export may take.
+)
+
+// otlpTracesURL returns the traces URL of an OTLP/HTTP endpoint.
+func otlpTracesURL(endpoint string) string {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if strings.HasSuffix(endpoint, otlpTracesPath) {
+		return endpoint
+	}
+	return endpoint + otlpTracesPath
+}
+
+// OTLPExporter posts spans to an OpenTelemetry collector with OTLP/HTTP in its JSON encoding.
+type OTLPExporter struct {
+	Endpoint    string            // The traces URL, ex: http://localhost:4318/v1/traces.
+	Headers     map[string]string // Sent with each export (optional).
+	ServiceName string            // The service.name resource attribute.
+}
+
+// OTLP/JSON structures, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // 64 bit integers are strings in OTLP/JSON.
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// Export posts the spans to the collector.
+func (e *OTLPExporter) Export(spans []*Span) error {
+	rs := otlpResourceSpans{
+		Resource: otlpResource{Attributes: otlpAttributes(map[string]interface{}{
+			"service.name":    e.ServiceName,
+			"service.version": version,
+		})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: applicationName, Version: version}}},
+	}
+	for _, s := range spans {
+		sp := otlpSpan{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.SpanID[:]),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.StatusCode, Message: s.StatusMessage},
+		}
+		if s.ParentID != [8]byte{} {
+			sp.ParentSpanID = hex.EncodeToString(s.ParentID[:])
+		}
+		rs.ScopeSpans[0].Spans = append(rs.ScopeSpans[0].Spans, sp)
+	}
+	b, err := json.Marshal(&otlpTraces{ResourceSpans: []otlpResourceSpans{rs}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(httpPost, e.Endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	cl := &http.Client{Timeout: otlpExportTimeout}
+	resp, err := cl.Do(req)
+	if err != nil {
+		return fmt.Errorf("telemetry: %s", err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("telemetry: the collector answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// otlpAttributes converts attributes to OTLP key values, in order.
+func otlpAttributes(attrs map[string]interface{}) []otlpKeyValue {
+	var result []otlpKeyValue
+	for k, v := range attrs {
+		kv := otlpKeyValue{Key: k}
+		switch v := v.(type) {
+		case bool:
+			kv.Value.BoolValue = &v
+		case int:
+			s := strconv.Itoa(v)
+			kv.Value.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			kv.Value.IntValue = &s
+		case float64:
+			kv.Value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			kv.Value.StringValue = &s
+		}
+		result = append(result, kv)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// StdoutExporter writes spans as JSON lines, for local debugging.
+type StdoutExporter struct {
+	Out io.Writer // Where the spans are written, ex: os.Stderr.
+}
+
+// stdoutSpan is a span written by StdoutExporter.
+type stdoutSpan struct {
+	Name       string                 `json:"name"`
+	TraceID    string                 `json:"traceId"`
+	SpanID     string                 `json:"spanId"`
+	ParentID   string                 `json:"parentSpanId,omitempty"`
+	Kind       string                 `json:"kind"`
+	Start      time.Time              `json:"start"`
+	Duration   string                 `json:"duration"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Status     string                 `json:"status,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Export writes the spans.
+func (e *StdoutExporter) Export(spans []*Span) error {
+	enc := json.NewEncoder(e.Out)
+	for _, s := range spans {
+		ss := stdoutSpan{
+			Name:       s.Name,
+			TraceID:    hex.EncodeToString(s.TraceID[:]),
+			SpanID:     hex.EncodeToString(s.SpanID[:]),
+			Kind:       "internal",
+			Start:      s.Start,
+			Duration:   s.End.Sub(s.Start).String(),
+			Attributes: s.Attributes,
+			Error:      s.StatusMessage,
+		}
+		if s.ParentID != [8]byte{} {
+			ss.ParentID = hex.EncodeToString(s.ParentID[:])
+		}
+		if s.Kind == SpanKindClient {
+			ss.Kind = "client"
+		}
+		switch s.StatusCode {
+		case StatusOK:
+			ss.Status = "ok"
+		case StatusError:
+			ss.Status = "error"
+		}
+		if err := enc.Encode(&ss); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Release states reported by helm.
+const (
+	ReleaseDeployed = "deployed"
+	ReleaseFailed   = "failed"
+)
+
+// Contents of a release revision.
+const (
+	ContentValues   = "values"   // The values the chart was rendered with.
+	ContentManifest = "manifest" // The rendered kube manifests.
+	ContentNotes    = "notes"    // The notes of the chart.
+)
+
+// Release is the current state of a helm release in a namespace.
+type Release struct {
+	Name       string    `json:"name"`       // The release name.
+	Namespace  string    `json:"namespace"`  // The namespace the release is deployed to.
+	Revision   int       `json:"revision"`   // The current revision.
+	Updated    time.Time `json:"updated"`    // When the current revision was deployed.
+	Status     string    `json:"status"`     // deployed, failed, pending-upgrade etc.
+	Chart      string    `json:"chart"`      // The chart name and version.
+	AppVersion string    `json:"appVersion"` // The app version of the chart.
+	VersionTag string    `json:"versionTag"` // The docker version tag deployed.
+}
+
+// Releases returns the releases deployed to a namespace.
+func (c *Client) Releases(namespace string) ([]Release, error) {
+	resp, err := c.List(namespace, "json")
+	if err != nil {
+		return nil, err
+	}
+	var releases []Release
+	if err := resp.Decode(&releases); err != nil {
+		return nil, fmt.Errorf("decode releases: %w", err)
+	}
+	return releases, nil
+}
+
+// FindRelease returns the release of an application in a namespace. Releases are matched by
+// name, or by the application name suffixed with the namespace, ex: myapp-dev.
+func (c *Client) FindRelease(name string, namespace string) (*Release, error) {
+	releases, err := c.Releases(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range releases {
+		if r.Name == name || r.Name == fmt.Sprintf("%s-%s", name, namespace) {
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("release %s %w in namespace %s", name, ErrNotFound, namespace)
+}
+
+// WaitForRelease polls a release until it is deployed with the version tag, it fails or the
+// timeout expires. An empty tag accepts any deployed version.
+func (c *Client) WaitForRelease(name string, namespace string, tag string, timeout time.Duration) (*Release, error) {
+	poll := c.polling()
+	deadline := time.Now().Add(timeout)
+	for {
+		r, err := poll.FindRelease(name, namespace)
+		if err == nil {
+			switch {
+			case r.Status == ReleaseFailed:
+				return r, fmt.Errorf("release %s failed in namespace %s", r.Name, namespace)
+			case r.Status == ReleaseDeployed && (tag == "" || r.VersionTag == tag):
+				return r, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return nil, err
+			}
+			return r, fmt.Errorf("%w waiting for release %s in namespace %s (status %s)", ErrTimeout, r.Name, namespace, r.Status)
+		}
+		time.Sleep(releaseWaitInterval)
+	}
+}
+
+// DryRun is a deploy rendered by the server but not applied.
+type DryRun struct {
+	Release string `json:"release"` // The release that would be deployed.
+	Current string `json:"current"` // The manifest deployed now; empty for a new release.
+	Planned string `json:"planned"` // The manifest the deploy would apply.
+}
+
+// DeployDryRun asks the server to render a deploy of a version tag without applying it.
+func (c *Client) DeployDryRun(name string, versionTag string, namespace string) (*DryRun, error) {
+	payload, err := json.Marshal(&DeployRequest{
+		Name:       name,
+		Namespace:  namespace,
+		VersionTag: versionTag,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(httpPost, fmt.Sprintf("%s%s", c.Url, httpRouteReleaseDryRun), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.sendRequest(req, "releases", httpRouteReleasesVersion)
+	if err != nil {
+		return nil, err
+	}
+	var d DryRun
+	if err := resp.Decode(&d); err != nil {
+		return nil, fmt.Errorf("decode dry run: %w", err)
+	}
+	return &d, nil
+}
+
+// ReleaseContent returns the values, manifest or notes of a revision of a release as YAML or
+// text. A revision of 0 is the current revision.
+func (c *Client) ReleaseContent(release string, content string, revision int) (string, error) {
+	var route string
+	switch content {
+	case ContentValues:
+		route = httpRouteReleaseValues
+	case ContentManifest:
+		route = httpRouteReleaseManifest
+	case ContentNotes:
+		route = httpRouteReleaseNotes
+	default:
+		return "", fmt.Errorf("unknown release content %q (values|manifest|notes)", content)
+	}
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(route, release)), nil)
+	if err != nil {
+		return "", err
+	}
+	if revision > 0 {
+		q := req.URL.Query()
+		q.Add("r", strconv.Itoa(revision))
+		req.URL.RawQuery = q.Encode()
+	}
+	resp, err := c.sendRequest(req, "releases", httpRouteReleasesVersion)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message, nil
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Verb is an operation a resource kind supports on the server.
+type Verb string
+
+const (
+	VerbList     Verb = "list"     // GET ListRoute
+	VerbDescribe Verb = "describe" // GET DescribeRoute
+	VerbRestart  Verb = "restart"  // PATCH RestartRoute
+)
+
+// ResourceKind describes a namespaced kube resource exposed by the server. Commands and
+// client calls are generated from it, so adding a resource only requires registering one.
+type ResourceKind struct {
+	Name          string   // Plural name used in the Accept header and the default command, ex: pods.
+	Singular      string   // Singular name used in help text, ex: pod.
+	Command       string   // Command name if different than Name, ex: hpa (optional).
+	Aliases       []string // Other command names (optional).
+	Description   string   // Long description of the top level command (optional).
+	ListRoute     string   // Route to list the resources, ex: /pods.
+	DescribeRoute string   // Route to describe a resource, ex: /pods/%s.
+	RestartRoute  string   // Route to restart a resource, ex: /deployments/%s/restart.
+	APIVersion    string   // The API version of the routes, ex: v1.0.0.
+	Columns       []string // Columns displayed by the server when listing.
+	Verbs         []Verb   // Operations supported.
+}
+
+// CommandName returns the name of the command for the resource.
+func (k *ResourceKind) CommandName() string {
+	if k.Command != "" {
+		return k.Command
+	}
+	return k.Name
+}
+
+// Supports returns true if the verb is an operation of the resource.
+func (k *ResourceKind) Supports(v Verb) bool {
+	for _, s := range k.Verbs {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the descriptor has the routes needed for its verbs.
+func (k *ResourceKind) validate() error {
+	if k.Name == "" || k.Singular == "" || k.APIVersion == "" {
+		return fmt.Errorf("resource kind requires a name, singular name and API version")
+	}
+	for _, v := range k.Verbs {
+		var route string
+		switch v {
+		case VerbList:
+			route = k.ListRoute
+		case VerbDescribe:
+			route = k.DescribeRoute
+		case VerbRestart:
+			route = k.RestartRoute
+		default:
+			return fmt.Errorf("resource %s: unknown verb %q", k.Name, v)
+		}
+		if route == "" {
+			return fmt.Errorf("resource %s: verb %s requires a route", k.Name, v)
+		}
+	}
+	return nil
+}
+
+var (
+	resourcesMu sync.RWMutex
+	resources   = map[string]ResourceKind{}
+)
+
+func init() {
+	builtin := []ResourceKind{
+		{Name: "configmaps", Singular: "configmap", ListRoute: httpRouteConfigmaps, DescribeRoute: httpRouteConfigmap,
+			APIVersion: httpRouteConfigmapsVersion, Columns: []string{"NAME", "DATA", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "cronjobs", Singular: "cronjob", ListRoute: httpRouteCronjobs, DescribeRoute: httpRouteCronjob,
+			APIVersion: httpRouteCronjobsVersion, Columns: []string{"NAME", "SCHEDULE", "SUSPEND", "ACTIVE", "LAST SCHEDULE", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "daemonsets", Singular: "daemonset", ListRoute: httpRouteDaemonsets, DescribeRoute: httpRouteDaemonset,
+			RestartRoute: httpRouteDaemonsetRestart, APIVersion: httpRouteDaemonsetsVersion,
+			Columns: []string{"NAME", "DESIRED", "CURRENT", "READY", "UP-TO-DATE", "AVAILABLE", "AGE"},
+			Verbs:   []Verb{VerbList, VerbDescribe, VerbRestart}},
+		{Name: "deployments", Singular: "deployment", ListRoute: httpRouteDeployments, DescribeRoute: httpRouteDeployment,
+			RestartRoute: httpRouteDeploymentRestart, APIVersion: httpRouteDeploymentsVersion,
+			Columns: []string{"NAME", "READY", "UP-TO-DATE", "AVAILABLE", "AGE"},
+			Verbs:   []Verb{VerbList, VerbDescribe, VerbRestart}},
+		{Name: "hpas", Singular: "horizontal pod autoscaler", Command: "hpa", ListRoute: httpRouteHpas, DescribeRoute: httpRouteHpa,
+			APIVersion: httpRouteHpasVersion, Columns: []string{"NAME", "REFERENCE", "TARGETS", "MINPODS", "MAXPODS", "REPLICAS", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "ingresses", Singular: "ingress", ListRoute: httpRouteIngresses, DescribeRoute: httpRouteIngress,
+			APIVersion: httpRouteIngressesVersion, Columns: []string{"NAME", "HOSTS", "ADDRESS", "PORTS", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "jobs", Singular: "job", ListRoute: httpRouteJobs, DescribeRoute: httpRouteJob,
+			APIVersion: httpRouteJobsVersion, Columns: []string{"NAME", "COMPLETIONS", "DURATION", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "pdbs", Singular: "pod disruption budget", Command: "pdb", ListRoute: httpRoutePdbs, DescribeRoute: httpRoutePdb,
+			APIVersion: httpRoutePdbsVersion, Columns: []string{"NAME", "MIN AVAILABLE", "MAX UNAVAILABLE", "ALLOWED DISRUPTIONS", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "pods", Singular: "pod", ListRoute: httpRoutePods, DescribeRoute: httpRoutePod,
+			APIVersion: httpRoutePodsVersion, Columns: []string{"NAME", "READY", "STATUS", "RESTARTS", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "secrets", Singular: "secret", ListRoute: httpRouteSecrets, DescribeRoute: httpRouteSecret,
+			APIVersion: httpRouteSecretsVersion, Columns: []string{"NAME", "TYPE", "KEYS", "SIZE", "LAST MODIFIED"},
+			Description: "Top level command for displaying secret metadata in a namespace. Values are never shown by default.",
+			Verbs:       []Verb{VerbList}},
+		{Name: "services", Singular: "service", ListRoute: httpRouteServices, DescribeRoute: httpRouteService,
+			APIVersion: httpRouteServicesVersion, Columns: []string{"NAME", "TYPE", "CLUSTER-IP", "EXTERNAL-IP", "PORTS", "AGE"},
+			Verbs: []Verb{VerbList, VerbDescribe}},
+		{Name: "statefulsets", Singular: "statefulset", ListRoute: httpRouteStatefulsets, DescribeRoute: httpRouteStatefulset,
+			RestartRoute: httpRouteStatefulsetRestart, APIVersion: httpRouteStatefulsetsVersion,
+			Columns: []string{"NAME", "READY", "AGE"},
+			Verbs:   []Verb{VerbList, VerbDescribe, VerbRestart}},
+	}
+	for _, k := range builtin {
+		if err := RegisterResource(k); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterResource adds a resource kind to the registry. Programs embedding the client use
+// this to expose extra resource kinds served by their k8ctl-server.
+func RegisterResource(k ResourceKind) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	if _, ok := resources[k.Name]; ok {
+		return fmt.Errorf("resource %s is already registered", k.Name)
+	}
+	resources[k.Name] = k
+	return nil
+}
+
+// Resources returns all registered resource kinds sorted by name.
+func Resources() []ResourceKind {
+	resourcesMu.RLock()
+	defer resourcesMu.RUnlock()
+	result := make([]ResourceKind, 0, len(resources))
+	for _, k := range resources {
+		result = append(result, k)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// LookupResource finds a registered resource kind by its name, command name or alias.
+func LookupResource(name string) (*ResourceKind, bool) {
+	resourcesMu.RLock()
+	defer resourcesMu.RUnlock()
+	if k, ok := resources[name]; ok {
+		return &k, true
+	}
+	for _, k := range resources {
+		if k.Command == name {
+			return &k, true
+		}
+		for _, a := range k.Aliases {
+			if a == name {
+				return &k, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ResourceDescribe prints out the details of a resource.
+func (c *Client) ResourceDescribe(kind string, name string, namespace string) (*Response, error) {
+	k, err := lookupVerb(kind, VerbDescribe)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Add("n", namespace)
+	return c.resourceRequest(k, httpGet, fmt.Sprintf(k.DescribeRoute, name), q, nil)
+}
+
+// ResourceList prints out a list of resources.
+func (c *Client) ResourceList(kind string, namespace string, format string) (*Response, error) {
+	k, err := lookupVerb(kind, VerbList)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Add("n", namespace)
+	q.Add("f", format)
+	return c.resourceRequest(k, httpGet, k.ListRoute, q, nil)
+}
+
+// ResourceRestart restarts all pods under a resource.
+func (c *Client) ResourceRestart(kind string, name string, namespace string, memo string) (*Response, error) {
+	k, err := lookupVerb(kind, VerbRestart)
+	if err != nil {
+		return nil, err
+	}
+	return c.resourceRequest(k, httpPatch, fmt.Sprintf(k.RestartRoute, name), nil, &RestartRequest{
+		Memo:      memo,
+		Namespace: namespace,
+	})
+}
+
+// lookupVerb finds a resource kind and checks that it supports the verb.
+func lookupVerb(kind string, v Verb) (*ResourceKind, error) {
+	k, ok := LookupResource(kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %s", kind)
+	}
+	if !k.Supports(v) {
+		return nil, fmt.Errorf("resource %s does not support %s", k.Name, v)
+	}
+	return k, nil
+}
+
+// resourceRequest builds and sends a request for a resource kind.
+func (c *Client) resourceRequest(k *ResourceKind, method string, route string, q url.Values,
+	payload interface{}) (*Response, error) {
+	var body *bytes.Buffer
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(b)
+	} else {
+		body = &bytes.Buffer{}
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.Url, route), body)
+	if err != nil {
+		return nil, err
+	}
+	if q != nil {
+		req.URL.RawQuery = q.Encode()
+	}
+	return c.sendRequest(req, k.Name, k.APIVersion)
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deploy strategies.
+const (
+	StrategyCanary    = "canary"    // Shift traffic to the new version in steps.
+	StrategyBlueGreen = "bluegreen" // Run the new version beside the old and switch all traffic at once.
+)
+
+// Rollout phases reported by the server.
+const (
+	RolloutProgressing = "progressing" // The new version is being deployed or traffic is shifting.
+	RolloutPaused      = "paused"      // Traffic is split, waiting for the next step or a promote.
+	RolloutPromoted    = "promoted"    // The new version is stable.
+	RolloutAborted     = "aborted"     // All traffic was returned to the stable version.
+	RolloutFailed      = "failed"      // The new version failed its health checks.
+)
+
+// Rollout is the state of a canary or blue/green deploy of a release.
+type Rollout struct {
+	Release       string    `json:"release"`           // The release name.
+	Namespace     string    `json:"namespace"`         // The namespace of the release.
+	Strategy      string    `json:"strategy"`          // canary or bluegreen.
+	Phase         string    `json:"phase"`             // progressing, paused, promoted, aborted or failed.
+	StableTag     string    `json:"stableTag"`         // The version tag serving the remaining traffic.
+	NewTag        string    `json:"newTag"`            // The version tag being rolled out.
+	Weight        int       `json:"weight"`            // Percent of traffic sent to the new version.
+	Replicas      int       `json:"replicas"`          // Replicas of the new version.
+	ReadyReplicas int       `json:"readyReplicas"`     // Replicas of the new version passing health checks.
+	Healthy       bool      `json:"healthy"`           // True if the new version passes its health checks.
+	Message       string    `json:"message,omitempty"` // Detail of the phase, ex: why it failed.
+	Updated       time.Time `json:"updated"`           // When the rollout last changed.
+}
+
+// RolloutRequest is the payload to start or step a rollout.
+type RolloutRequest struct {
+	Memo       string `json:"memo,omitempty"`       // Optional text to display in slack etc.
+	Namespace  string `json:"namespace"`            // The namespace of the release.
+	Strategy   string `json:"strategy,omitempty"`   // canary or bluegreen, when starting.
+	VersionTag string `json:"versionTag,omitempty"` // The docker version tag, when starting.
+	Weight     int    `json:"weight"`               // Percent of traffic sent to the new version.
+}
+
+// ParseSteps parses canary steps, ex: 10,25,50,100. Steps are increasing percents of traffic.
+func ParseSteps(s string) ([]int, error) {
+	var steps []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || n < 1 || n > 100 {
+			return nil, fmt.Errorf("invalid step %q: steps are percents from 1 to 100", f)
+		}
+		if len(steps) > 0 && n <= steps[len(steps)-1] {
+			return nil, fmt.Errorf("invalid steps %q: steps must increase", s)
+		}
+		steps = append(steps, n)
+	}
+	return steps, nil
+}
+
+// StartRollout deploys a new version of a release beside the stable one with a share of traffic.
+// A blue/green rollout starts with a weight of 0.
+func (c *Client) StartRollout(name string, versionTag string, namespace string, strategy string, weight int,
+	memo string) (*Response, error) {
+	return c.rolloutRequest(httpPost, fmt.Sprintf(httpRouteReleaseRollout, name), &RolloutRequest{
+		Memo:       memo,
+		Namespace:  namespace,
+		Strategy:   strategy,
+		VersionTag: versionTag,
+		Weight:     weight,
+	})
+}
+
+// SetRolloutWeight changes the share of traffic sent to the new version of a rollout.
+func (c *Client) SetRolloutWeight(name string, namespace string, weight int, memo string) (*Response, error) {
+	return c.rolloutRequest(httpPatch, fmt.Sprintf(httpRouteReleaseRollout, name), &RolloutRequest{
+		Memo:      memo,
+		Namespace: namespace,
+		Weight:    weight,
+	})
+}
+
+// PromoteRollout sends all traffic to the new version of a rollout and removes the old one.
+func (c *Client) PromoteRollout(name string, namespace string, memo string) (*Response, error) {
+	return c.rolloutRequest(httpPut, fmt.Sprintf(httpRouteReleaseRolloutPromote, name), &RolloutRequest{
+		Memo:      memo,
+		Namespace: namespace,
+		Weight:    100,
+	})
+}
+
+// AbortRollout returns all traffic to the stable version and removes the new one.
+func (c *Client) AbortRollout(name string, namespace string, memo string) (*Response, error) {
+	req, err := http.NewRequest(httpDelete, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteReleaseRollout, name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Add("n", namespace)
+	if memo != "" {
+		q.Add("m", memo)
+	}
+	req.URL.RawQuery = q.Encode()
+	return c.sendRequest(req, "rollouts", httpRouteRolloutsVersion)
+}
+
+// RolloutStatus returns the state of the rollout of a release.
+func (c *Client) RolloutStatus(name string, namespace string) (*Rollout, error) {
+	req, err := http.NewRequest(httpGet, fmt.Sprintf("%s%s", c.Url, fmt.Sprintf(httpRouteReleaseRollout, name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("n", namespace)
+	q.Add("f", "json")
+	req.URL.RawQuery = q.Encode()
+	resp, err := c.sendRequest(req, "rollouts", httpRouteRolloutsVersion)
+	if err != nil {
+		return nil, err
+	}
+	var r Rollout
+	if err := resp.Decode(&r); err != nil {
+		return nil, fmt.Errorf("decode rollout: %w", err)
+	}
+	return &r, nil
+}
+
+// WaitForRollout polls a rollout until the new version is healthy at the weight, it fails or
+// the timeout expires.
+func (c *Client) WaitForRollout(name string, namespace string, weight int, timeout time.Duration) (*Rollout, error) {
+	poll := c.polling()
+	deadline := time.Now().Add(timeout)
+	for {
+		r, err := poll.RolloutStatus(name, namespace)
+		if err == nil {
+			switch {
+			case r.Phase == RolloutFailed || r.Phase == RolloutAborted:
+				return r, fmt.Errorf("rollout of %s in namespace %s %s: %s", name, namespace, r.Phase, r.Message)
+			case r.Healthy && r.Weight == weight:
+				return r, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return nil, err
+			}
+			return r, fmt.Errorf("%w waiting for rollout of %s in namespace %s to be healthy (%d/%d ready)",
+				ErrTimeout, name, namespace, r.ReadyReplicas, r.Replicas)
+		}
+		time.Sleep(releaseWaitInterval)
+	}
+}
+
+// WatchRollout polls a rollout for a duration and returns early with an error if the new
+// version fails its health checks.
+func (c *Client) WatchRollout(name string, namespace string, d time.Duration) (*Rollout, error) {
+	poll := c.polling()
+	deadline := time.Now().Add(d)
+	var r *Rollout
+	for {
+		var err error
+		if r, err = poll.RolloutStatus(name, namespace); err == nil {
+			switch {
+			case r.Phase == RolloutFailed || r.Phase == RolloutAborted:
+				return r, fmt.Errorf("rollout of %s in namespace %s %s: %s", name, namespace, r.Phase, r.Message)
+			case !r.Healthy:
+				return r, fmt.Errorf("rollout of %s in namespace %s is unhealthy (%d/%d ready)", name, namespace,
+					r.ReadyReplicas, r.Replicas)
+			}
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return r, nil
+		}
+		if remaining > releaseWaitInterval {
+			remaining = releaseWaitInterval
+		}
+		time.Sleep(remaining)
+	}
+}
+
+// rolloutRequest sends a rollout payload to the server.
+func (c *Client) rolloutRequest(method string, route string, rr *RolloutRequest) (*Response, error) {
+	payload, err := json.Marshal(rr)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.Url, route), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	return c.sendRequest(req, "rollouts", httpRouteRolloutsVersion)
+}
@@ -0,0 +1,417 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot changes.
+const (
+	SnapshotAdded   = "added"
+	SnapshotRemoved = "removed"
+	SnapshotChanged = "changed"
+)
+
+const (
+	snapshotManifest = "snapshot.json" // The file of the manifest in the archive.
+	snapshotReleases = "releases"      // The directory of the releases in the archive.
+)
+
+// SnapshotManifest describes a snapshot.
+type SnapshotManifest struct {
+	Cluster       string    `json:"cluster"`                 // The cluster captured.
+	Namespace     string    `json:"namespace"`               // The namespace captured.
+	Taken         time.Time `json:"taken"`                   // When the capture started.
+	ClientVersion string    `json:"clientVersion"`           // The version of the client that took it.
+	ServerVersion string    `json:"serverVersion,omitempty"` // The version of the server, if known.
+	Kinds         []string  `json:"kinds"`                   // The resource kinds captured.
+	Errors        []string  `json:"errors,omitempty"`        // What could not be captured.
+}
+
+// Snapshot is the state of a namespace captured for offline browsing: the list and describe
+// output of every resource kind, and the status and history of every release. Files holds the
+// captured output by path in the archive:
+//
+//	snapshot.json                      the manifest
+//	releases/list.txt, list.json       the releases
+//	releases/NAME/status.txt           the status of a release
+//	releases/NAME/history.json         the history of a release
+//	KIND/list.txt, list.json           the resources of a kind
+//	KIND/describe/NAME.txt             the details of a resource
+type Snapshot struct {
+	Manifest SnapshotManifest
+	Files    map[string][]byte
+}
+
+// CaptureSnapshot captures a namespace. Errors for a kind or an object are recorded in the
+// manifest and the capture goes on, so a snapshot taken during an incident is as complete as
+// the server allows; only failing to list the releases fails the capture.
+func CaptureSnapshot(cl Interface, cluster string, namespace string, kinds []ResourceKind) (*Snapshot, error) {
+	s := &Snapshot{
+		Manifest: SnapshotManifest{
+			Cluster:       cluster,
+			Namespace:     namespace,
+			Taken:         time.Now().UTC(),
+			ClientVersion: version,
+		},
+		Files: make(map[string][]byte),
+	}
+	if d, err := cl.Discover(); err == nil {
+		s.Manifest.ServerVersion = d.ServerVersion
+	}
+
+	// Releases.
+	resp, err := cl.List(namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	s.Files[path.Join(snapshotReleases, "list.txt")] = []byte(resp.Message)
+	releases, err := cl.Releases(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if s.Files[path.Join(snapshotReleases, "list.json")], err = json.MarshalIndent(releases, "", "  "); err != nil {
+		return nil, err
+	}
+	for _, r := range releases {
+		if !validSnapshotName(r.Name) {
+			continue
+		}
+		if resp, err := cl.Status(r.Name, ""); err != nil {
+			s.failed("status of release %s: %s", r.Name, err)
+		} else {
+			s.Files[path.Join(snapshotReleases, r.Name, "status.txt")] = []byte(resp.Message)
+		}
+		if entries, err := cl.ReleaseHistory(r.Name); err != nil {
+			s.failed("history of release %s: %s", r.Name, err)
+		} else {
+			s.Files[path.Join(snapshotReleases, r.Name, "history.json")], _ = json.MarshalIndent(entries, "", "  ")
+		}
+	}
+
+	// Kube resources.
+	for _, k := range kinds {
+		if !k.Supports(VerbList) {
+			continue
+		}
+		s.Manifest.Kinds = append(s.Manifest.Kinds, k.Name)
+		resp, err := cl.ResourceList(k.Name, namespace, "")
+		if err != nil {
+			s.failed("list of %s: %s", k.Name, err)
+			continue
+		}
+		s.Files[path.Join(k.Name, "list.txt")] = []byte(resp.Message)
+		if resp, err = cl.ResourceList(k.Name, namespace, "json"); err != nil {
+			s.failed("list of %s: %s", k.Name, err)
+			continue
+		}
+		s.Files[path.Join(k.Name, "list.json")] = []byte(resp.Message)
+		if !k.Supports(VerbDescribe) {
+			continue
+		}
+		for _, name := range s.Names(k.Name) {
+			if resp, err := cl.ResourceDescribe(k.Name, name, namespace); err != nil {
+				s.failed("%s %s: %s", k.Singular, name, err)
+			} else {
+				s.Files[path.Join(k.Name, "describe", name+".txt")] = []byte(resp.Message)
+			}
+		}
+	}
+	return s, nil
+}
+
+// failed records what could not be captured.
+func (s *Snapshot) failed(format string, args ...interface{}) {
+	s.Manifest.Errors = append(s.Manifest.Errors, fmt.Sprintf(format, args...))
+}
+
+// validSnapshotName returns true if a name can be used as a path in the archive.
+func validSnapshotName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// File returns a captured file, or an error if the snapshot does not hold it.
+func (s *Snapshot) File(name string) ([]byte, error) {
+	b, ok := s.Files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the snapshot", name)
+	}
+	return b, nil
+}
+
+// Objects returns the rows of the list of a resource kind, keyed by lowercased column.
+func (s *Snapshot) Objects(kind string) []map[string]string {
+	var rows []map[string]string
+	if b, ok := s.Files[path.Join(kind, "list.json")]; ok {
+		json.Unmarshal(b, &rows)
+	}
+	return rows
+}
+
+// Names returns the names of the resources of a kind, in order.
+func (s *Snapshot) Names(kind string) []string {
+	var names []string
+	for _, row := range s.Objects(kind) {
+		if validSnapshotName(row["name"]) {
+			names = append(names, row["name"])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Releases returns the releases of the snapshot.
+func (s *Snapshot) Releases() []Release {
+	var releases []Release
+	if b, ok := s.Files[path.Join(snapshotReleases, "list.json")]; ok {
+		json.Unmarshal(b, &releases)
+	}
+	return releases
+}
+
+// History returns the history of a release.
+func (s *Snapshot) History(release string) ([]HistoryEntry, error) {
+	b, err := s.File(path.Join(snapshotReleases, release, "history.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Write saves the snapshot as a gzipped tar archive.
+func (s *Snapshot) Write(file string) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	m, err := json.MarshalIndent(&s.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	names := []string{snapshotManifest}
+	files := map[string][]byte{snapshotManifest: m}
+	for name, b := range s.Files {
+		names = append(names, name)
+		files[name] = b
+	}
+	sort.Strings(names[1:])
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), ModTime: s.Manifest.Taken}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// ReadSnapshot loads a snapshot written by Write.
+func ReadSnapshot(file string) (*Snapshot, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a snapshot: %s", file, err)
+	}
+	s := &Snapshot{Files: make(map[string][]byte)}
+	tr := tar.NewReader(gz)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a snapshot: %s", file, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == snapshotManifest {
+			if err := json.Unmarshal(b, &s.Manifest); err != nil {
+				return nil, fmt.Errorf("%s: invalid manifest: %s", file, err)
+			}
+			found = true
+			continue
+		}
+		s.Files[path.Clean(hdr.Name)] = b
+	}
+	if !found {
+		return nil, fmt.Errorf("%s is not a snapshot: no %s", file, snapshotManifest)
+	}
+	return s, nil
+}
+
+// SnapshotChange is a difference between two snapshots.
+type SnapshotChange struct {
+	Kind    string   `json:"kind"`              // releases or a resource kind.
+	Name    string   `json:"name"`              // The release or resource.
+	Change  string   `json:"change"`            // added, removed or changed.
+	Details []string `json:"details,omitempty"` // The fields that changed, ex: STATUS Running -> Error.
+	Diff    string   `json:"diff,omitempty"`    // The unified diff of the status or describe output.
+}
+
+// snapshotIgnoredFields are list columns that change without the resource changing.
+var snapshotIgnoredFields = map[string]bool{"age": true, "namespace": true}
+
+// DiffSnapshots returns the releases and resources added, removed or changed from a to b.
+// Changes of the age of a resource are ignored.
+func DiffSnapshots(a *Snapshot, b *Snapshot) []SnapshotChange {
+	changes := []SnapshotChange{}
+
+	// Releases.
+	ra, rb := make(map[string]Release), make(map[string]Release)
+	for _, r := range a.Releases() {
+		ra[r.Name] = r
+	}
+	for _, r := range b.Releases() {
+		rb[r.Name] = r
+	}
+	for _, name := range unionKeys(ra, rb) {
+		from, inA := ra[name]
+		to, inB := rb[name]
+		c := SnapshotChange{Kind: snapshotReleases, Name: name}
+		switch {
+		case !inA:
+			c.Change = SnapshotAdded
+			c.Details = []string{fmt.Sprintf("revision %d %s %s", to.Revision, to.VersionTag, to.Status)}
+		case !inB:
+			c.Change = SnapshotRemoved
+		default:
+			c.Details = releaseChanges(&from, &to)
+			status := path.Join(snapshotReleases, name, "status.txt")
+			c.Diff = textDiff(a.Files[status], b.Files[status], status)
+			if len(c.Details) == 0 && c.Diff == "" {
+				continue
+			}
+			c.Change = SnapshotChanged
+		}
+		changes = append(changes, c)
+	}
+
+	// Kube resources.
+	kinds := make(map[string]bool)
+	for _, k := range append(append([]string{}, a.Manifest.Kinds...), b.Manifest.Kinds...) {
+		kinds[k] = true
+	}
+	for _, kind := range unionKeys(kinds, nil) {
+		oa, ob := objectsByName(a, kind), objectsByName(b, kind)
+		for _, name := range unionKeys(oa, ob) {
+			from, inA := oa[name]
+			to, inB := ob[name]
+			c := SnapshotChange{Kind: kind, Name: name}
+			switch {
+			case !inA:
+				c.Change = SnapshotAdded
+			case !inB:
+				c.Change = SnapshotRemoved
+			default:
+				c.Details = fieldChanges(from, to)
+				describe := path.Join(kind, "describe", name+".txt")
+				c.Diff = textDiff(a.Files[describe], b.Files[describe], describe)
+				if len(c.Details) == 0 && c.Diff == "" {
+					continue
+				}
+				c.Change = SnapshotChanged
+			}
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// releaseChanges describes the differences between two states of a release.
+func releaseChanges(a *Release, b *Release) []string {
+	var details []string
+	if a.Revision != b.Revision {
+		details = append(details, fmt.Sprintf("REVISION %d -> %d", a.Revision, b.Revision))
+	}
+	for _, f := range []struct{ name, from, to string }{
+		{"TAG", a.VersionTag, b.VersionTag},
+		{"STATUS", a.Status, b.Status},
+		{"CHART", a.Chart, b.Chart},
+		{"APP VERSION", a.AppVersion, b.AppVersion},
+	} {
+		if f.from != f.to {
+			details = append(details, fmt.Sprintf("%s %s -> %s", f.name, f.from, f.to))
+		}
+	}
+	return details
+}
+
+// fieldChanges describes the differences between two list rows of a resource.
+func fieldChanges(a map[string]string, b map[string]string) []string {
+	var details []string
+	for _, f := range unionKeys(a, b) {
+		if snapshotIgnoredFields[f] || f == "name" || a[f] == b[f] {
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s %s -> %s", strings.ToUpper(f), a[f], b[f]))
+	}
+	return details
+}
+
+// textDiff returns the unified diff of two captured outputs, empty if they are the same.
+func textDiff(a []byte, b []byte, name string) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	return UnifiedDiff(string(a), string(b), "a/"+name, "b/"+name)
+}
+
+// objectsByName returns the list rows of a resource kind by name.
+func objectsByName(s *Snapshot, kind string) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	for _, row := range s.Objects(kind) {
+		result[row["name"]] = row
+	}
+	return result
+}
+
+// unionKeys returns the keys of two maps with the same key type, in order.
+func unionKeys(a interface{}, b interface{}) []string {
+	seen := make(map[string]bool)
+	for _, m := range []interface{}{a, b} {
+		if m == nil {
+			continue
+		}
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			seen[k.String()] = true
+		}
+	}
+	var keys []string
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
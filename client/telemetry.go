@@ -0,0 +1,239 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telemetry exporters.
+const (
+	ExporterNone   = "none"   // Spans are not recorded.
+	ExporterOTLP   = "otlp"   // Spans are posted to an OpenTelemetry collector with OTLP/HTTP.
+	ExporterStdout = "stdout" // Spans are written as JSON lines to stderr, for local debugging.
+)
+
+// Span kinds, as numbered by OTLP.
+const (
+	SpanKindInternal = 1 // An operation of the client, ex: a command.
+	SpanKindClient   = 3 // A request to the server.
+)
+
+// Span status codes, as numbered by OTLP.
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// TelemetryConfig configures the OpenTelemetry traces, under telemetry in the config. The
+// standard OTEL_* environment variables override it.
+type TelemetryConfig struct {
+	Exporter    string            `mapstructure:"exporter"`     // otlp, stdout or none (default: none, or otlp if an endpoint is set).
+	Endpoint    string            `mapstructure:"endpoint"`     // The OTLP/HTTP URL, ex: http://localhost:4318.
+	Headers     map[string]string `mapstructure:"headers"`      // Sent with each export, ex: an API key (optional).
+	ServiceName string            `mapstructure:"service_name"` // The service.name of the spans (default: k8ctl).
+}
+
+// ApplyEnv overrides the config with the OTEL_* environment variables: OTEL_SDK_DISABLED,
+// OTEL_TRACES_EXPORTER (otlp, console or none), OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS and OTEL_SERVICE_NAME.
+func (tc *TelemetryConfig) ApplyEnv() {
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+		tc.Endpoint = strings.TrimSuffix(v, "/")
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); v != "" {
+		tc.Endpoint = v
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); v != "" {
+		if tc.Headers == nil {
+			tc.Headers = make(map[string]string)
+		}
+		for _, kv := range strings.Split(v, ",") {
+			if i := strings.Index(kv, "="); i > 0 {
+				tc.Headers[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+			}
+		}
+	}
+	if v := os.Getenv("OTEL_SERVICE_NAME"); v != "" {
+		tc.ServiceName = v
+	}
+	if v := os.Getenv("OTEL_TRACES_EXPORTER"); v != "" {
+		tc.Exporter = v
+		if v == "console" {
+			tc.Exporter = ExporterStdout
+		}
+	}
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		tc.Exporter = ExporterNone
+	}
+}
+
+// SpanExporter sends ended spans to a backend.
+type SpanExporter interface {
+	Export(spans []*Span) error
+}
+
+// Tracer records the spans of the commands and of their requests to the server, and exports
+// them when flushed. It is safe for concurrent use.
+type Tracer struct {
+	ServiceName string       // The service.name of the spans.
+	Exporter    SpanExporter // Where the spans are sent.
+
+	mu    sync.Mutex
+	ended []*Span
+}
+
+// NewTracer returns a tracer exporting as configured, or nil if telemetry is disabled.
+// Spans written by the stdout exporter go to out.
+func NewTracer(tc *TelemetryConfig, out io.Writer) (*Tracer, error) {
+	exporter := tc.Exporter
+	if exporter == "" && tc.Endpoint != "" {
+		exporter = ExporterOTLP
+	}
+	t := &Tracer{ServiceName: tc.ServiceName}
+	if t.ServiceName == "" {
+		t.ServiceName = applicationName
+	}
+	switch exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+		if tc.Endpoint == "" {
+			return nil, fmt.Errorf("telemetry: the otlp exporter needs an endpoint")
+		}
+		t.Exporter = &OTLPExporter{Endpoint: otlpTracesURL(tc.Endpoint), Headers: tc.Headers,
+			ServiceName: t.ServiceName}
+	case ExporterStdout:
+		t.Exporter = &StdoutExporter{Out: out}
+	default:
+		return nil, fmt.Errorf("telemetry: unknown exporter %s", exporter)
+	}
+	return t, nil
+}
+
+// Start begins a span, as a child of parent if given. A nil tracer returns a nil span, on which
+// every method is a no-op.
+func (t *Tracer) Start(parent *Span, name string, kind int) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: make(map[string]interface{}),
+		tracer:     t,
+	}
+	if parent != nil {
+		s.TraceID = parent.TraceID
+		s.ParentID = parent.SpanID
+	} else {
+		rand.Read(s.TraceID[:])
+	}
+	rand.Read(s.SpanID[:])
+	return s
+}
+
+// StartRemote begins a span continuing a trace started by another process, as described by a
+// W3C traceparent header value, ex: the TRACEPARENT of a CI job. An invalid value starts a
+// new trace.
+func (t *Tracer) StartRemote(traceparent string, name string, kind int) *Span {
+	s := t.Start(nil, name, kind)
+	if s == nil {
+		return nil
+	}
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return s
+	}
+	var traceID [16]byte
+	var parentID [8]byte
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return s
+	}
+	if _, err := hex.Decode(parentID[:], []byte(parts[2])); err != nil || parentID == [8]byte{} {
+		return s
+	}
+	s.TraceID, s.ParentID = traceID, parentID
+	return s
+}
+
+// Flush exports the spans ended since the last flush.
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.ended
+	t.ended = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	return t.Exporter.Export(spans)
+}
+
+// Span is a timed operation of a trace.
+type Span struct {
+	TraceID       [16]byte               // The trace the span belongs to.
+	SpanID        [8]byte                // The span.
+	ParentID      [8]byte                // The parent span, zero for the root of the trace.
+	Name          string                 // What the span measures, ex: k8ctl releases deploy.
+	Kind          int                    // SpanKindInternal or SpanKindClient.
+	Start         time.Time              // When the span started.
+	End           time.Time              // When the span ended.
+	Attributes    map[string]interface{} // string, bool, int, int64 or float64 values.
+	StatusCode    int                    // StatusUnset, StatusOK or StatusError.
+	StatusMessage string                 // The error of a failed span.
+
+	tracer *Tracer
+	mu     sync.Mutex
+	done   bool
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// Finish ends the span, failed if err is not nil, and queues it for export. Only the first
+// call has an effect.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	s.done = true
+	s.End = time.Now()
+	if err != nil {
+		s.StatusCode, s.StatusMessage = StatusError, err.Error()
+	}
+	s.mu.Unlock()
+	s.tracer.mu.Lock()
+	s.tracer.ended = append(s.tracer.ended, s)
+	s.tracer.mu.Unlock()
+}
+
+// TraceParent returns the W3C traceparent header value identifying the span, ex:
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%x-%x-01", s.TraceID, s.SpanID)
+}
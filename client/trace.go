@@ -0,0 +1,317 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trace levels.
+const (
+	TraceRequests = 1 // The method, URL, status and timing of each request.
+	TraceHeaders  = 2 // Adds the request and response headers.
+	TraceBodies   = 3 // Adds the request and response bodies.
+)
+
+// traceBodyLimit is the size of a body beyond which the log truncates it.
+const traceBodyLimit = 64 * 1024
+
+// redactedBody replaces the response bodies that may hold secret values.
+const redactedBody = "<redacted>"
+
+// Trace logs the HTTP exchanges of a client. The Authorization header is always redacted, as
+// are the response bodies that may hold secret values.
+type Trace struct {
+	Level int       // What is logged: TraceRequests, TraceHeaders or TraceBodies; 0 logs nothing.
+	Out   io.Writer // Where the log is written, ex: os.Stderr.
+	HAR   *HARLog   // Records the exchanges into a HAR file, if set.
+
+	mu sync.Mutex
+}
+
+// exchange is a request and its response, or the error that prevented it.
+type exchange struct {
+	resource string // The resource of the Accept header, ex: secrets.
+	req      *http.Request
+	reqBody  []byte
+	resp     *http.Response
+	respBody []byte
+	err      error
+	start    time.Time
+	wait     time.Duration // Until the response headers.
+	total    time.Duration // Until the response body was read.
+}
+
+// responseBody returns the body of the response, redacted for secrets and for any request
+// revealing a value.
+func (x *exchange) responseBody() []byte {
+	if len(x.respBody) > 0 && (x.resource == "secrets" || x.req.URL.Query().Get("reveal") != "") {
+		return []byte(redactedBody)
+	}
+	return x.respBody
+}
+
+// record logs an exchange and adds it to the HAR file.
+func (t *Trace) record(x *exchange) {
+	if t == nil {
+		return
+	}
+	if t.HAR != nil {
+		t.HAR.add(x)
+	}
+	if t.Level < TraceRequests || t.Out == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if x.err != nil {
+		fmt.Fprintf(t.Out, "HTTP %s %s failed after %s: %s\n", x.req.Method, x.req.URL, roundDuration(x.total), x.err)
+	} else {
+		fmt.Fprintf(t.Out, "HTTP %s %s %s in %s\n", x.req.Method, x.req.URL, x.resp.Status, roundDuration(x.total))
+	}
+	if t.Level < TraceHeaders {
+		return
+	}
+	writeHeaders(t.Out, "> ", x.req.Header)
+	if t.Level >= TraceBodies && len(x.reqBody) > 0 {
+		writeBody(t.Out, "> ", x.reqBody)
+	}
+	if x.resp == nil {
+		return
+	}
+	writeHeaders(t.Out, "< ", x.resp.Header)
+	if t.Level >= TraceBodies && len(x.respBody) > 0 {
+		writeBody(t.Out, "< ", x.responseBody())
+	}
+}
+
+// cached logs a request answered from the cache without asking the server.
+func (t *Trace) cached(req *http.Request, age time.Duration) {
+	if t == nil || t.Level < TraceRequests || t.Out == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.Out, "HTTP %s %s from the cache, %s old\n", req.Method, req.URL, age.Round(time.Millisecond))
+}
+
+// writeHeaders logs headers in order, with the Authorization header redacted.
+func writeHeaders(w io.Writer, prefix string, h http.Header) {
+	var names []string
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range redactedHeader(name, h[name]) {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, name, v)
+		}
+	}
+}
+
+// writeBody logs a body, truncated beyond traceBodyLimit.
+func writeBody(w io.Writer, prefix string, body []byte) {
+	suffix := ""
+	if len(body) > traceBodyLimit {
+		suffix = fmt.Sprintf(" ... (%d bytes truncated)", len(body)-traceBodyLimit)
+		body = body[:traceBodyLimit]
+	}
+	fmt.Fprintf(w, "%s%s%s\n", prefix, body, suffix)
+}
+
+// redactedHeader returns the values of a header with credentials hidden.
+func redactedHeader(name string, values []string) []string {
+	if http.CanonicalHeaderKey(name) != "Authorization" {
+		return values
+	}
+	result := make([]string, len(values))
+	for i := range values {
+		result[i] = "Bearer <redacted>"
+	}
+	return result
+}
+
+// HARLog records HTTP exchanges in the HTTP Archive format (HAR 1.2). The file is rewritten
+// after every exchange so it is complete even if the command fails.
+type HARLog struct {
+	Path string // The file written.
+
+	mu      sync.Mutex
+	entries []harEntry
+	err     error
+}
+
+// NewHARLog returns a log writing to a HAR file.
+func NewHARLog(path string) *HARLog {
+	return &HARLog{Path: path}
+}
+
+// Err returns the first error writing the file, if any.
+func (h *HARLog) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// HAR 1.2 structures, see http://www.softwareishard.com/blog/har-12-spec/.
+type harFile struct {
+	Log harLogBody `json:"log"`
+}
+
+type harLogBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// add records an exchange and rewrites the file.
+func (h *HARLog) add(x *exchange) {
+	e := harEntry{
+		StartedDateTime: x.start,
+		Time:            milliseconds(x.total),
+		Request: harRequest{
+			Method:      x.req.Method,
+			URL:         x.req.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(x.req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(x.reqBody),
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: harTimings{Wait: milliseconds(x.wait), Receive: milliseconds(x.total - x.wait)},
+	}
+	for name, values := range x.req.URL.Query() {
+		for _, v := range values {
+			e.Request.QueryString = append(e.Request.QueryString, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.Slice(e.Request.QueryString, func(i, j int) bool { return e.Request.QueryString[i].Name < e.Request.QueryString[j].Name })
+	if len(x.reqBody) > 0 {
+		e.Request.PostData = &harPostData{MimeType: x.req.Header.Get("Content-Type"), Text: string(x.reqBody)}
+	}
+	if x.resp != nil {
+		e.Response.Status = x.resp.StatusCode
+		e.Response.StatusText = http.StatusText(x.resp.StatusCode)
+		e.Response.HTTPVersion = x.resp.Proto
+		e.Response.Headers = harHeaders(x.resp.Header)
+		e.Response.BodySize = len(x.respBody)
+		e.Response.Content = harContent{Size: len(x.respBody), MimeType: x.resp.Header.Get("Content-Type"),
+			Text: string(x.responseBody())}
+	}
+	if x.err != nil {
+		e.Comment = x.err.Error()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, e)
+	b, err := json.MarshalIndent(&harFile{Log: harLogBody{
+		Version: "1.2",
+		Creator: harCreator{Name: applicationName, Version: version},
+		Entries: h.entries,
+	}}, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(h.Path, b, 0600)
+	}
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+}
+
+// harHeaders returns headers in order, with the Authorization header redacted.
+func harHeaders(h http.Header) []harNameValue {
+	result := []harNameValue{}
+	for name, values := range h {
+		for _, v := range redactedHeader(name, values) {
+			result = append(result, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// roundDuration rounds a duration for the log, keeping microseconds below a millisecond.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// milliseconds returns a duration in fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testExchange returns an exchange of a GET answered with body.
+func testExchange(t *testing.T, resource string, url string, body string) *exchange {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer s3cr3t-token")
+	resp := &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Proto: "HTTP/1.1", Header: http.Header{}}
+	return &exchange{resource: resource, req: req, resp: resp, respBody: []byte(body), start: time.Now()}
+}
+
+func TestTraceRedaction(t *testing.T) {
+	tests := []struct {
+		resource string
+		url      string
+		redacted bool
+	}{
+		{"releases", "http://k8ctl/releases?n=dev", false},
+		{"secrets", "http://k8ctl/secrets/db?n=dev", true},
+		{"configmaps", "http://k8ctl/configmaps/db?n=dev&reveal=password", true},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		har := &HARLog{Path: filepath.Join(t.TempDir(), "trace.har")}
+		trace := &Trace{Level: TraceBodies, Out: &out, HAR: har}
+		trace.record(testExchange(t, tt.resource, tt.url, `{"message":"password: hunter2"}`))
+
+		b, err := ioutil.ReadFile(har.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f harFile
+		if err := json.Unmarshal(b, &f); err != nil {
+			t.Fatal(err)
+		}
+		for name, text := range map[string]string{"log": out.String(), "HAR": string(b)} {
+			if strings.Contains(text, "s3cr3t-token") {
+				t.Errorf("%s %s: the %s holds the token", tt.resource, tt.url, name)
+			}
+			if strings.Contains(text, "hunter2") == tt.redacted {
+				t.Errorf("%s %s: the %s holds the body: %t, want %t", tt.resource, tt.url, name, !tt.redacted,
+					!tt.redacted)
+			}
+		}
+		if got := f.Log.Entries[0].Response.Content.Text; tt.redacted && got != redactedBody {
+			t.Errorf("%s %s: HAR content = %q, want %q", tt.resource, tt.url, got, redactedBody)
+		}
+	}
+}
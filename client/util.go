@@ -2,12 +2,14 @@ package client
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 )
 
-// PrintErr formats an application error.
+// PrintErr prints an application error as a JSON object.
 func PrintErr(err string) {
-	fmt.Printf("{\"error\":\"%s\"}\n", err)
+	b, _ := json.Marshal(map[string]string{"error": err})
+	fmt.Println(string(b))
 }
 
 // createV4UUID returns a V4 RFC4122 compliant UUID.
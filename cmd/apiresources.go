@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+// apiResourcesCmd displays the capabilities of the server.
+var apiResourcesCmd = &cobra.Command{
+	Use:   "api-resources",
+	Short: "Display the resources supported by the server",
+	Long:  "Displays the version of the server along with the resources, verbs and API versions it supports.",
+	Args:  cobra.MaximumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		return runAPIResources(format)
+	},
+	Example: `k8ctl api-resources --help
+k8ctl api-resources --cluster nyc
+k8ctl api-resources -l nyc --format json`,
+}
+
+func init() {
+	RootCmd.AddCommand(apiResourcesCmd)
+	apiResourcesCmd.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+}
+
+// runAPIResources retrieves the capabilities of the server and refreshes the cache.
+func runAPIResources(format string) error {
+	d, err := clientFactory(cluster, clusterUrl, bearerToken, nil).Discover()
+	if err != nil {
+		return err
+	}
+	saveDiscovery(d)
+	if skew := d.VersionSkew(); skew != "" {
+		fmt.Fprintln(stderr, "Warning:", skew)
+	}
+	if format != "" {
+		return printObject(d, format)
+	}
+
+	fmt.Fprintf(stdout, "Server version: %s\n\n", d.ServerVersion)
+	ours := client.APIVersions()
+	var rows [][]string
+	for _, r := range d.Resources {
+		status := "ok"
+		if v, ok := ours[r.Name]; !ok {
+			status = "unknown to client"
+		} else if !d.SupportsVersion(r.Name, v) {
+			status = fmt.Sprintf("client speaks %s", v)
+		}
+		rows = append(rows, []string{r.Name, strings.Join(r.Verbs, ","), strings.Join(r.Versions, ","), status})
+	}
+	printTable([]string{"NAME", "VERBS", "VERSIONS", "STATUS"}, rows)
+	if len(d.ApprovalNamespaces) > 0 {
+		fmt.Fprintf(stdout, "\nNamespaces requiring approval: %s\n", strings.Join(d.ApprovalNamespaces, ", "))
+	}
+	return nil
+}
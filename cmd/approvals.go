@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	approvalsCmd = &cobra.Command{
+		Use:   "approvals",
+		Short: "Display and review deploys waiting for approval",
+		Long: `Top level command for deploys to namespaces that require approval. Such deploys are held on
+the server until a second person approves them, and are then applied by the server.`,
+		Example:     `k8ctl approvals --help (for subcommands)`,
+		Annotations: map[string]string{"resource": "approvals"},
+	}
+
+	approvalsSubCmdApprove = &cobra.Command{
+		Use:   "approve [flags] [ID]",
+		Short: "Approve a deploy",
+		Long: `Approve lets the server apply a deploy waiting for approval. The server reviews as the user of the
+bearer token and refuses the requester.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			override, err := cmd.Flags().GetString("override-freeze")
+			if err != nil {
+				return err
+			}
+			return runApprove(args[0], override)
+		},
+		Example: `k8ctl approvals approve --help
+k8ctl approvals approve --cluster nyc 4F2A9C1E`,
+	}
+
+	approvalsSubCmdList = &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List approval requests",
+		Long:  "List will display the deploys waiting for approval, or with another status if given.",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, status, format string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if status, err = cmd.Flags().GetString("status"); err != nil {
+				return err
+			}
+			if format, err = cmd.Flags().GetString("format"); err != nil {
+				return err
+			}
+			return runApprovalsList(namespace, status, format)
+		},
+		Example: `k8ctl approvals list --help
+k8ctl approvals list --cluster nyc
+k8ctl approvals list -l nyc -n prod --status rejected --format json`,
+	}
+
+	approvalsSubCmdReject = &cobra.Command{
+		Use:   "reject [flags] [ID]",
+		Short: "Reject a deploy",
+		Long:  "Reject refuses a deploy waiting for approval. The reason is shown to the requester.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reason, err := cmd.Flags().GetString("reason")
+			if err != nil {
+				return err
+			}
+			return runReject(args[0], reason)
+		},
+		Example: `k8ctl approvals reject --help
+k8ctl approvals reject --cluster nyc --reason "wait for the load test" 4F2A9C1E`,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(approvalsCmd)
+	approvalsCmd.AddCommand(approvalsSubCmdApprove)
+	approvalsCmd.AddCommand(approvalsSubCmdList)
+	approvalsCmd.AddCommand(approvalsSubCmdReject)
+
+	addOverrideFreezeFlag(approvalsSubCmdApprove)
+
+	approvalsSubCmdList.Flags().StringP("namespace", "n", "", "Namespace to report (optional: default is all)")
+	approvalsSubCmdList.Flags().String("status", client.ApprovalPending,
+		"Status to report (pending|approved|rejected|failed|expired; empty for all)")
+	approvalsSubCmdList.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+
+	approvalsSubCmdReject.Flags().String("reason", "", "Why the deploy is rejected. (required)")
+	approvalsSubCmdReject.MarkFlagRequired("reason")
+}
+
+// approvalOptions are the flags of a deploy that may require approval.
+type approvalOptions struct {
+	Wait    bool          // Wait for the deploy to be reviewed.
+	Timeout time.Duration // How long to wait.
+}
+
+// addApprovalFlags adds the flags of a deploy that may require approval.
+func addApprovalFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("wait-for-approval", false, "If the namespace requires approval, wait for the deploy to be reviewed")
+	cmd.Flags().Duration("approval-timeout", time.Hour, "How long to wait for approval")
+}
+
+// approvalFlags reads the flags of a deploy that may require approval.
+func approvalFlags(cmd *cobra.Command) (*approvalOptions, error) {
+	var opts approvalOptions
+	var err error
+	if opts.Wait, err = cmd.Flags().GetBool("wait-for-approval"); err != nil {
+		return nil, err
+	}
+	if opts.Timeout, err = cmd.Flags().GetDuration("approval-timeout"); err != nil {
+		return nil, err
+	}
+	return &opts, nil
+}
+
+// discoveryOf returns the capabilities of the server of a cluster: the cached ones of the
+// current cluster, or those asked of another. Servers without a discovery route return nil.
+func discoveryOf(cl client.Interface, clusterName string) (*client.Discovery, error) {
+	if clusterName == cluster {
+		if discovery == nil {
+			discovery = loadDiscovery()
+		}
+		return discovery, nil
+	}
+	d, err := cl.Discover()
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return d, err
+}
+
+// requestApproval submits a deploy for approval and, if asked, waits for it to be reviewed.
+func requestApproval(cl client.Interface, clusterName string, release string, tag string, namespace string,
+	memo string, opts *approvalOptions) error {
+	resp, err := audited(&operation{Event: "approval", Cluster: clusterName, Namespace: namespace, Release: release,
+		Tag: tag, Memo: memo}, func() (*client.Response, error) {
+		return cl.RequestApproval(release, tag, namespace, memo)
+	})
+	if err != nil {
+		return err
+	}
+	var a client.Approval
+	if err := resp.Decode(&a); err != nil {
+		return fmt.Errorf("decode approval: %w", err)
+	}
+	fmt.Fprintf(stdout, "Namespace %s of cluster %s requires approval. Deploy %s of %s %s is waiting for review.\n", namespace,
+		clusterName, a.ID, release, tag)
+	fmt.Fprintf(stdout, "A second person can run: k8ctl approvals approve --cluster %s %s\n", clusterName, a.ID)
+	if !opts.Wait {
+		return nil
+	}
+	fmt.Fprintf(stdout, "Waiting up to %s for approval\n", opts.Timeout)
+	r, err := cl.WaitForApproval(a.ID, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	switch r.Status {
+	case client.ApprovalApproved:
+		fmt.Fprintf(stdout, "Approved by %s. %s\n", r.Reviewer, r.Message)
+		return nil
+	case client.ApprovalRejected:
+		return fmt.Errorf("deploy %s was rejected by %s: %s", r.ID, r.Reviewer, r.Reason)
+	}
+	return fmt.Errorf("deploy %s is %s: %s", r.ID, r.Status, r.Message)
+}
+
+// Support functions to conduct the client call.
+
+func runApprove(id string, override string) error {
+	cl := newClient()
+	a, err := cl.Approval(id)
+	if err != nil {
+		return err
+	}
+	if a.Status != client.ApprovalPending {
+		return conflictError(fmt.Errorf("deploy %s is already %s", a.ID, a.Status))
+	}
+	note, err := checkFreeze(a.Namespace, a.Release, override)
+	if err != nil {
+		return err
+	}
+	memo := withOverride(withOverride(a.Memo, fmt.Sprintf("Requested by %s.", a.Requester)), note)
+	resp, err := audited(&operation{Event: "deploy", Namespace: a.Namespace, Release: a.Release, Tag: a.VersionTag,
+		Memo: memo}, func() (*client.Response, error) {
+		return cl.Approve(id)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
+
+func runApprovalsList(namespace string, status string, format string) error {
+	approvals, err := newClient().Approvals(namespace, status)
+	if err != nil {
+		return err
+	}
+	if format != "" {
+		return printObject(approvals, format)
+	}
+	var rows [][]string
+	for _, a := range approvals {
+		rows = append(rows, []string{a.ID, a.Release, a.Namespace, a.VersionTag, a.Requester,
+			a.RequestedAt.Local().Format(time.RFC3339), a.Status, a.Reviewer, a.Memo})
+	}
+	printTable([]string{"ID", "RELEASE", "NAMESPACE", "TAG", "REQUESTER", "REQUESTED", "STATUS", "REVIEWER", "MEMO"}, rows)
+	return nil
+}
+
+func runReject(id string, reason string) error {
+	cl := newClient()
+	a, err := cl.Approval(id)
+	if err != nil {
+		return err
+	}
+	resp, err := audited(&operation{Event: "approval", Namespace: a.Namespace, Release: a.Release, Tag: a.VersionTag,
+		Memo: fmt.Sprintf("Rejected: %s", reason)}, func() (*client.Response, error) {
+		return cl.Reject(id, reason)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
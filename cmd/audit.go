@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	auditMaxSizeMB  = 10 // Default size of the audit log before it is rotated.
+	auditMaxBackups = 5  // Default number of rotated audit logs to keep.
+)
+
+// auditCmd searches the local audit log.
+var auditCmd = &cobra.Command{
+	Use:   "audit [flags]",
+	Short: "Search the local audit log",
+	Long: `Displays entries of the local audit log, which records every deploy, rollback, delete,
+restart and secret reveal run from this machine. Use the request ID to find the matching
+entries in the server logs. If --cluster is given only entries for that cluster are shown.`,
+	Args: cobra.MaximumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var f client.AuditFilter
+		var since time.Duration
+		var format string
+		var limit int
+		var err error
+		if since, err = cmd.Flags().GetDuration("since"); err != nil {
+			return err
+		}
+		if since > 0 {
+			f.Since = time.Now().Add(-since)
+		}
+		if f.User, err = cmd.Flags().GetString("user"); err != nil {
+			return err
+		}
+		if f.Namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+			return err
+		}
+		if f.Command, err = cmd.Flags().GetString("command"); err != nil {
+			return err
+		}
+		if f.RequestID, err = cmd.Flags().GetString("request-id"); err != nil {
+			return err
+		}
+		if f.Result, err = cmd.Flags().GetString("result"); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("cluster") {
+			f.Cluster = cluster
+		}
+		if limit, err = cmd.Flags().GetInt("limit"); err != nil {
+			return err
+		}
+		if format, err = cmd.Flags().GetString("format"); err != nil {
+			return err
+		}
+		return runAudit(&f, limit, format)
+	},
+	Example: `k8ctl audit --help
+k8ctl audit --since 24h
+k8ctl audit --cluster nyc --namespace prod --command deploy
+k8ctl audit --result failure --limit 10
+k8ctl audit --request-id 9C1F2E4A-51B7-4D0A-8E3C-1B2A3C4D5E6F --format json`,
+}
+
+func init() {
+	RootCmd.AddCommand(auditCmd)
+	auditCmd.Flags().Duration("since", 0, "Only entries newer than this, ex: 2h, 7d is 168h (optional)")
+	auditCmd.Flags().String("user", "", "Only entries by this user (optional)")
+	auditCmd.Flags().StringP("namespace", "n", "", "Only entries in this namespace (optional)")
+	auditCmd.Flags().String("command", "", "Only entries whose command contains this text, ex: deploy (optional)")
+	auditCmd.Flags().String("request-id", "", "Only the entry with this request ID (optional)")
+	auditCmd.Flags().String("result", "", "Only entries with this result (optional: success|failure)")
+	auditCmd.Flags().Int("limit", 0, "Only the most recent entries (optional)")
+	auditCmd.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+}
+
+// auditLog returns the audit log configured under audit in the config. The log is opened once
+// per run, so that the records of concurrent calls are serialized by its lock.
+func auditLog() *client.AuditLog {
+	if audit == nil {
+		viper.SetDefault("audit.path", filepath.Join(stateDir(), "audit.jsonl"))
+		viper.SetDefault("audit.max_size_mb", auditMaxSizeMB)
+		viper.SetDefault("audit.max_backups", auditMaxBackups)
+		audit = client.NewAuditLog(viper.GetString("audit.path"), viper.GetInt64("audit.max_size_mb")<<20,
+			viper.GetInt("audit.max_backups"))
+	}
+	return audit
+}
+
+// operation describes a mutating command for the audit log and notifications.
+type operation struct {
+	Event     string // deploy, promote, rollback, delete, restart, rollout, approval, freeze or reveal.
+	Cluster   string // The cluster changed, if not the selected one, ex: the target of a promotion.
+	Namespace string // The namespace, if known.
+	Release   string // The release or resource name.
+	Tag       string // The version tag deployed.
+	Memo      string // The memo sent to the server.
+}
+
+// audited runs a mutating call, records it in the audit log and sends notifications of its
+// outcome. Failing to write the log or notify is reported but does not fail the command.
+func audited(op *operation, call func() (*client.Response, error)) (*client.Response, error) {
+	start := time.Now()
+	resp, err := call()
+	record(op, auditEntry(op, start, resp, err))
+	return resp, err
+}
+
+// auditEntry returns the audit log entry of a mutating call started at start. It reads no
+// config, so that concurrent calls can build their entries.
+func auditEntry(op *operation, start time.Time, resp *client.Response, err error) *client.AuditEntry {
+	target := op.Cluster
+	if target == "" {
+		target = cluster
+	}
+	e := &client.AuditEntry{
+		Time:       start,
+		User:       currentUser(),
+		Cluster:    target,
+		Namespace:  op.Namespace,
+		Args:       os.Args[1:],
+		Result:     client.AuditSuccess,
+		DurationMs: time.Since(start).Nanoseconds() / int64(time.Millisecond),
+	}
+	if invokedCmd != nil {
+		e.Command = invokedCmd.CommandPath()
+	}
+	if resp != nil {
+		e.RequestID = resp.RequestID
+		e.Status = resp.Status
+	}
+	if err != nil {
+		e.Result = client.AuditFailure
+		e.Error = err.Error()
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) {
+			e.RequestID = apiErr.RequestID
+			e.Status = http.StatusText(apiErr.StatusCode)
+		}
+	}
+	return e
+}
+
+// record appends an entry to the audit log and sends notifications of the operation. It reads
+// the config, which is not safe for concurrent use: concurrent calls build their entries with
+// auditEntry and leave recording them to a single goroutine.
+func record(op *operation, e *client.AuditEntry) {
+	if !viper.GetBool("audit.disabled") {
+		if err := auditLog().Append(e); err != nil {
+			fmt.Fprintln(stderr, "Warning: cannot write the audit log:", err)
+		}
+	}
+	notify(op, e)
+}
+
+// runAudit prints the entries selected by the filter.
+func runAudit(f *client.AuditFilter, limit int, format string) error {
+	entries, err := auditLog().Search(f)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	if format != "" {
+		return printObject(entries, format)
+	}
+	var rows [][]string
+	for _, e := range entries {
+		result := e.Result
+		if e.Error != "" {
+			result = fmt.Sprintf("%s: %s", e.Result, e.Error)
+		}
+		rows = append(rows, []string{e.Time.Local().Format(time.RFC3339), e.User, e.Cluster, e.Namespace, e.Command,
+			e.RequestID, (time.Duration(e.DurationMs) * time.Millisecond).String(), result})
+	}
+	printTable([]string{"TIME", "USER", "CLUSTER", "NAMESPACE", "COMMAND", "REQUEST ID", "DURATION", "RESULT"}, rows)
+	return nil
+}
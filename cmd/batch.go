@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+// Results of a release in a batch.
+const (
+	batchDeployed   = "deployed"
+	batchFailed     = "failed"
+	batchSkipped    = "skipped"
+	batchRolledBack = "rolled back"
+)
+
+var releasesSubCmdDeployBatch = &cobra.Command{
+	Use:   "deploy-batch [flags]",
+	Short: "Deploy the releases of a plan in dependency order",
+	Long: `Deploy-batch deploys every release of a plan file. Releases are deployed in stages: a release
+deploys once all the releases it dependsOn are healthy, and independent releases deploy in
+parallel up to --concurrency. If a release fails the remaining stages are skipped and, with
+--on-failure rollback, the releases deployed by the batch are rolled back. A summary is printed at the end.
+
+The plan is YAML:
+
+  namespace: prod
+  memo: Release 42
+  releases:
+    - name: db-migrations
+      tag: k8-1.4.0-2001
+    - name: api
+      tag: k8-1.4.0-2001
+      dependsOn: [db-migrations]
+    - name: web
+      tag: k8-1.4.0-2001
+      dependsOn: [api]`,
+	Args: cobra.MaximumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var file, memo, onFailure, override, format string
+		var concurrency int
+		var timeout time.Duration
+		var err error
+		if file, err = cmd.Flags().GetString("file"); err != nil {
+			return err
+		}
+		if memo, err = cmd.Flags().GetString("memo"); err != nil {
+			return err
+		}
+		if concurrency, err = cmd.Flags().GetInt("concurrency"); err != nil {
+			return err
+		}
+		if timeout, err = cmd.Flags().GetDuration("timeout"); err != nil {
+			return err
+		}
+		if onFailure, err = cmd.Flags().GetString("on-failure"); err != nil {
+			return err
+		}
+		if override, err = cmd.Flags().GetString("override-freeze"); err != nil {
+			return err
+		}
+		if format, err = cmd.Flags().GetString("format"); err != nil {
+			return err
+		}
+		return runDeployBatch(file, memo, concurrency, timeout, onFailure, override, format)
+	},
+	Example: `k8ctl releases deploy-batch --help
+k8ctl releases deploy-batch --cluster nyc -f plan.yaml
+k8ctl releases deploy-batch -l nyc -f plan.yaml --concurrency 4 --timeout 10m --on-failure rollback
+k8ctl releases deploy-batch -l nyc -f plan.yaml -m "Release 42 hotfix" --format json`,
+}
+
+func init() {
+	releasesCmd.AddCommand(releasesSubCmdDeployBatch)
+	releasesSubCmdDeployBatch.Flags().StringP("file", "f", "", "The plan file. (required)")
+	releasesSubCmdDeployBatch.Flags().StringP("memo", "m", "", "Memo for every deploy (optional: default is the memo of the plan)")
+	releasesSubCmdDeployBatch.Flags().Int("concurrency", 3, "Releases deployed in parallel within a stage")
+	releasesSubCmdDeployBatch.Flags().Duration("timeout", 5*time.Minute, "How long to wait for each release to be healthy")
+	releasesSubCmdDeployBatch.Flags().String("on-failure", "stop", "What to do when a release fails (stop|rollback)")
+	releasesSubCmdDeployBatch.Flags().String("format", "", "Format of the summary (optional: json|yaml)")
+	addOverrideFreezeFlag(releasesSubCmdDeployBatch)
+	releasesSubCmdDeployBatch.MarkFlagRequired("file")
+}
+
+// batchResult is the outcome of a release of a batch.
+type batchResult struct {
+	Stage     int    `json:"stage" yaml:"stage"`
+	Release   string `json:"release" yaml:"release"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Tag       string `json:"tag" yaml:"tag"`
+	Result    string `json:"result" yaml:"result"`
+	Duration  string `json:"duration,omitempty" yaml:"duration,omitempty"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	previous *client.Release    // The release before the deploy, used to roll back.
+	op       *operation         // The deploy, to record in the audit log.
+	entry    *client.AuditEntry // The audit log entry of the deploy, nil if it was not sent.
+}
+
+// Support functions to conduct the client call.
+
+func runDeployBatch(file string, memo string, concurrency int, timeout time.Duration, onFailure string,
+	override string, format string) error {
+	if onFailure != "stop" && onFailure != "rollback" {
+		return fmt.Errorf("invalid --on-failure %q (stop|rollback)", onFailure)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	plan, err := client.LoadPlan(file)
+	if err != nil {
+		return err
+	}
+	stages, _ := plan.Stages()
+
+	// Check approvals and freezes of every namespace before deploying anything.
+	cl := newClient()
+	d, err := discoveryOf(cl, cluster)
+	if err != nil {
+		return err
+	}
+	notes := map[string]string{}
+	for _, r := range plan.Releases {
+		if _, ok := notes[r.Namespace]; ok {
+			continue
+		}
+		if d.RequiresApproval(r.Namespace) {
+			return fmt.Errorf("namespace %s requires approval; deploy its releases with releases deploy", r.Namespace)
+		}
+		if notes[r.Namespace], err = checkFreeze(r.Namespace, r.Name, override); err != nil {
+			return err
+		}
+	}
+
+	var results []*batchResult
+	failed := false
+	for i, stage := range stages {
+		stageResults := make([]*batchResult, len(stage))
+		for j, r := range stage {
+			stageResults[j] = &batchResult{Stage: i + 1, Release: r.Name, Namespace: r.Namespace, Tag: r.Tag,
+				Result: batchSkipped}
+		}
+		results = append(results, stageResults...)
+		if failed {
+			continue
+		}
+		fmt.Fprintf(stderr, "Stage %d of %d: deploying %d release(s)\n", i+1, len(stages), len(stage))
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for j, r := range stage {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(r client.PlanRelease, res *batchResult) {
+				defer func() { <-sem; wg.Done() }()
+				m := r.Memo
+				if memo != "" {
+					m = memo
+				}
+				deployBatchRelease(cl, r, withOverride(m, notes[r.Namespace]), timeout, res)
+			}(r, stageResults[j])
+		}
+		wg.Wait()
+		for _, res := range stageResults {
+			if res.entry != nil {
+				record(res.op, res.entry)
+			}
+			if res.Result == batchFailed {
+				failed = true
+			}
+		}
+	}
+
+	if failed && onFailure == "rollback" {
+		rollbackBatch(cl, results)
+	}
+	if format != "" {
+		if err := printObject(results, format); err != nil {
+			return err
+		}
+	} else {
+		var rows [][]string
+		for _, r := range results {
+			rows = append(rows, []string{strconv.Itoa(r.Stage), r.Release, r.Namespace, r.Tag, r.Result, r.Duration, r.Error})
+		}
+		printTable([]string{"STAGE", "RELEASE", "NAMESPACE", "TAG", "RESULT", "DURATION", "ERROR"}, rows)
+	}
+	if failed {
+		return fmt.Errorf("batch deploy of %s failed", file)
+	}
+	return nil
+}
+
+// deployBatchRelease deploys a release of a batch and waits for it to be healthy. It runs
+// concurrently, so the deploy is left in res for the caller to record.
+func deployBatchRelease(cl client.Interface, r client.PlanRelease, memo string, timeout time.Duration,
+	res *batchResult) {
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start).Round(time.Second).String() }()
+	res.Result = batchFailed
+	prev, err := cl.FindRelease(r.Name, r.Namespace)
+	switch {
+	case err == nil:
+		res.previous = prev
+	case !isNotFound(err):
+		// Deploying would leave a release that cannot be rolled back.
+		res.Error = fmt.Sprintf("cannot read the current revision: %s", err)
+		return
+	}
+	res.op = &operation{Event: "deploy", Namespace: r.Namespace, Release: r.Name, Tag: r.Tag, Memo: memo}
+	deployed := time.Now()
+	resp, err := cl.Deploy(r.Name, r.Tag, r.Namespace, memo)
+	res.entry = auditEntry(res.op, deployed, resp, err)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	if _, err := cl.WaitForRelease(r.Name, r.Namespace, r.Tag, timeout); err != nil {
+		res.Error = err.Error()
+		return
+	}
+	res.Result = batchDeployed
+}
+
+// rollbackBatch rolls back the releases a batch changed to their previous revision, latest
+// stage first. Releases first installed by the batch have no previous revision and are left.
+func rollbackBatch(cl client.Interface, results []*batchResult) {
+	for i := len(results) - 1; i >= 0; i-- {
+		res := results[i]
+		if res.Result == batchSkipped || res.entry == nil {
+			continue // Not deployed.
+		}
+		if res.previous == nil {
+			res.Error = appendError(res.Error, "not rolled back: no previous revision")
+			continue
+		}
+		revision := strconv.Itoa(res.previous.Revision)
+		memo := fmt.Sprintf("Batch deploy failed; rolled back by %s.", currentUser())
+		fmt.Fprintf(stderr, "Rolling back %s to revision %s\n", res.previous.Name, revision)
+		_, err := audited(&operation{Event: "rollback", Namespace: res.Namespace, Release: res.previous.Name,
+			Tag: "revision " + revision, Memo: memo}, func() (*client.Response, error) {
+			return cl.RollbackWithMemo(res.previous.Name, revision, memo)
+		})
+		if err != nil {
+			res.Error = appendError(res.Error, "rollback failed: "+err.Error())
+			continue
+		}
+		res.Result = batchRolledBack
+	}
+}
+
+// appendError joins error texts of a batch result.
+func appendError(current string, text string) string {
+	if current == "" {
+		return text
+	}
+	return fmt.Sprintf("%s; %s", current, text)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/composer22/k8ctl/client/fake"
+)
+
+func TestDeployBatchReleaseLookupFailure(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) { m.Deploy("api", "dev", "k8-1.0.0-1", "") })
+	s.Fail(fake.Failure{Method: http.MethodGet, Path: "/releases", Status: http.StatusServiceUnavailable, Times: 1})
+	r := client.PlanRelease{Name: "api", Tag: "k8-1.0.0-2", Namespace: "dev"}
+	var res batchResult
+	deployBatchRelease(s.NewClient(), r, "", time.Second, &res)
+	if res.Result != batchFailed || !strings.Contains(res.Error, "cannot read the current revision") {
+		t.Errorf("result = %s (%s), want failed before deploying", res.Result, res.Error)
+	}
+	s.Update(func(m *fake.Model) {
+		if tag := m.Releases["api-dev"].VersionTag; tag != "k8-1.0.0-1" {
+			t.Errorf("release deployed with %s after the lookup failed", tag)
+		}
+	})
+}
+
+func TestDeployBatchReleaseNew(t *testing.T) {
+	s := newTestServer(t)
+	r := client.PlanRelease{Name: "api", Tag: "k8-1.0.0-1", Namespace: "dev"}
+	var res batchResult
+	deployBatchRelease(s.NewClient(), r, "", time.Minute, &res)
+	if res.Result != batchDeployed || res.previous != nil {
+		t.Errorf("result = %s (%s), previous %v, want a new release deployed", res.Result, res.Error, res.previous)
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local cache of server responses",
+		Long: `Top level command for the local cache of server responses, enabled with cache.enabled in the
+config.`,
+		Example: `k8ctl cache --help (for subcommands)`,
+	}
+
+	cacheSubCmdClear = &cobra.Command{
+		Use:   "clear [flags]",
+		Short: "Clear the cache",
+		Long: `Clear removes the cached responses and capabilities of the server of a cluster, or of every
+cluster with --all.`,
+		Args: cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, err := cmd.Flags().GetBool("all")
+			if err != nil {
+				return err
+			}
+			return runCacheClear(all)
+		},
+		Example: `k8ctl cache clear --help
+k8ctl cache clear --cluster nyc
+k8ctl cache clear --all`,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheSubCmdClear)
+
+	cacheSubCmdClear.Flags().Bool("all", false, "Clear the cache of every cluster (optional)")
+}
+
+// cacheDir returns the directory of the cache of a cluster.
+func cacheDir(name string) string {
+	return filepath.Join(stateDir(), "cache", name)
+}
+
+// responseCache returns the cache of the responses of a cluster configured under cache in the
+// config, or nil if it is disabled. With --no-cache every response is revalidated with the
+// server, and changes still invalidate the cache.
+func responseCache(name string) *client.Cache {
+	if !viper.GetBool("cache.enabled") {
+		return nil
+	}
+	viper.SetDefault("cache.ttl", client.DefaultCacheTTL)
+	ttls := make(map[string]time.Duration)
+	for resource := range viper.GetStringMap("cache.ttls") {
+		ttls[resource] = viper.GetDuration("cache.ttls." + resource)
+	}
+	c := client.NewCache(filepath.Join(cacheDir(name), "responses"), viper.GetDuration("cache.ttl"), ttls)
+	c.Revalidate = noCache
+	return c
+}
+
+// Support functions to conduct the client call.
+
+// runCacheClear removes the cache of the selected cluster, or of all clusters.
+func runCacheClear(all bool) error {
+	dir := cacheDir(cluster)
+	if all {
+		dir = filepath.Join(stateDir(), "cache")
+	}
+	if err := client.NewCache(dir, 0, nil).Clear(); err != nil {
+		return err
+	}
+	if all {
+		fmt.Fprintln(stdout, "Cache cleared.")
+		return nil
+	}
+	fmt.Fprintf(stdout, "Cache of cluster %s cleared.\n", cluster)
+	return nil
+}
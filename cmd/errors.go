@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/composer22/k8ctl/client"
+)
+
+// Exit codes of the application.
+const (
+	ExitOK        = 0 // The command succeeded.
+	ExitError     = 1 // The command failed for another reason.
+	ExitUsage     = 2 // Unknown command, or invalid flags or arguments.
+	ExitConfig    = 3 // The config file is missing or invalid, or the cluster is not in it.
+	ExitAuth      = 4 // The server refused the token or the operation (401, 403).
+	ExitNotFound  = 5 // The release or resource does not exist (404).
+	ExitConflict  = 6 // The operation conflicts with the state of the cluster, ex: a freeze (409).
+	ExitServer    = 7 // The server failed or cannot be reached (5xx).
+	ExitTimeout   = 8 // The server or a deploy did not answer in time.
+	ExitUnhealthy = 9 // A health check found a problem.
+)
+
+// exitKinds names the exit codes in JSON errors.
+var exitKinds = map[int]string{
+	ExitError:     "error",
+	ExitUsage:     "usage",
+	ExitConfig:    "config",
+	ExitAuth:      "auth",
+	ExitNotFound:  "not_found",
+	ExitConflict:  "conflict",
+	ExitServer:    "server",
+	ExitTimeout:   "timeout",
+	ExitUnhealthy: "unhealthy",
+}
+
+// cobraUsageErrors are the prefixes of the errors cobra returns for invalid commands and
+// arguments, which it does not type.
+var cobraUsageErrors = []string{
+	"unknown command",
+	"invalid argument",
+	"requires at least",
+	"accepts ",
+	"required flag(s)",
+}
+
+// exitError is an error along with the exit code it maps to.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// usageError marks an error as a misuse of the command line.
+func usageError(err error) error {
+	return &exitError{code: ExitUsage, err: err}
+}
+
+// configError marks an error of the config.
+func configError(err error) error {
+	return &exitError{code: ExitConfig, err: err}
+}
+
+// conflictError marks an operation refused because of the state of the cluster.
+func conflictError(err error) error {
+	return &exitError{code: ExitConflict, err: err}
+}
+
+// unhealthyError marks a health check that found a problem.
+func unhealthyError(err error) error {
+	return &exitError{code: ExitUnhealthy, err: err}
+}
+
+// ExitCode returns the exit code of an error returned by the commands, ExitOK if nil.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return ExitAuth
+		case apiErr.StatusCode == http.StatusNotFound:
+			return ExitNotFound
+		case apiErr.StatusCode == http.StatusConflict:
+			return ExitConflict
+		case apiErr.StatusCode == http.StatusRequestTimeout || apiErr.StatusCode == http.StatusGatewayTimeout:
+			return ExitTimeout
+		case apiErr.StatusCode >= http.StatusInternalServerError:
+			return ExitServer
+		}
+		return ExitError
+	}
+	if errors.Is(err, client.ErrTimeout) {
+		return ExitTimeout
+	}
+	if errors.Is(err, client.ErrNotFound) {
+		return ExitNotFound
+	}
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return ExitError // A local file: the syscall.Errno it wraps would match net.Error below.
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ExitTimeout
+		}
+		return ExitServer
+	}
+	for _, prefix := range cobraUsageErrors {
+		if strings.HasPrefix(err.Error(), prefix) {
+			return ExitUsage
+		}
+	}
+	return ExitError
+}
+
+// isNotFound returns true if an error means the release or resource does not exist, rather
+// than that it could not be looked up.
+func isNotFound(err error) bool {
+	var apiErr *client.APIError
+	return errors.Is(err, client.ErrNotFound) || errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// jsonError is an error printed with --output json.
+type jsonError struct {
+	Error     string `json:"error"`               // The message.
+	Kind      string `json:"kind"`                // The kind of the exit code, ex: not_found.
+	Code      int    `json:"code"`                // The exit code.
+	Status    int    `json:"status,omitempty"`    // The HTTP status of the server, if it answered.
+	RequestID string `json:"requestId,omitempty"` // The X-Request-ID of the request, if sent.
+}
+
+// PrintError writes an error of the commands to w, as a JSON object with --output json and
+// otherwise as text.
+func PrintError(w io.Writer, err error) {
+	code := ExitCode(err)
+	if outputFormat != "json" {
+		fmt.Fprintln(w, "Error:", err)
+		return
+	}
+	je := jsonError{Error: err.Error(), Kind: exitKinds[code], Code: code}
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		je.Status = apiErr.StatusCode
+		je.RequestID = apiErr.RequestID
+	}
+	b, _ := json.Marshal(&je)
+	fmt.Fprintln(w, string(b))
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterCmd = &cobra.Command{
+		Use:   "exporter [flags]",
+		Short: "Expose the state of clusters as Prometheus metrics",
+		Long: `Exporter runs until interrupted. It reads the releases, deployments, pods, jobs and cronjobs of
+each cluster from its k8ctl-server every --interval, and serves them as Prometheus metrics on
+/metrics: release revision and status, deployment replica readiness, pod restarts, job outcomes
+and cronjob last run age. Teams without access to the monitoring of a cluster can alert
+through the same restricted API as the commands.
+
+Every namespace is read unless --namespaces is given. A resource that cannot be read is
+reported as a warning and by k8ctl_exporter_scrape_success, and has no samples until it
+can be read again.`,
+		Args: cobra.MaximumNArgs(0),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The first of --clusters stands for --cluster, which the config requires.
+			if clusters, _ := cmd.Flags().GetStringSlice("clusters"); len(clusters) > 0 && !cmd.Flags().Changed("cluster") {
+				cluster = clusters[0]
+			}
+			return RootCmd.PersistentPreRunE(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var listen string
+			var clusters, namespaces []string
+			var interval time.Duration
+			var err error
+			if listen, err = cmd.Flags().GetString("listen"); err != nil {
+				return err
+			}
+			if clusters, err = cmd.Flags().GetStringSlice("clusters"); err != nil {
+				return err
+			}
+			if namespaces, err = cmd.Flags().GetStringSlice("namespaces"); err != nil {
+				return err
+			}
+			if interval, err = cmd.Flags().GetDuration("interval"); err != nil {
+				return err
+			}
+			return runExporter(listen, clusters, namespaces, interval)
+		},
+		Example: `k8ctl exporter --help
+k8ctl exporter --cluster nyc
+k8ctl exporter --listen :9300 --clusters nyc,boston
+k8ctl exporter --clusters nyc --namespaces dev,prod --interval 1m`,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(exporterCmd)
+
+	exporterCmd.Flags().String("listen", ":9300", "Address to serve the metrics on")
+	exporterCmd.Flags().StringSlice("clusters", nil, "Clusters to read (optional: default is --cluster)")
+	exporterCmd.Flags().StringSlice("namespaces", nil, "Namespaces to read (optional: default is all)")
+	exporterCmd.Flags().Duration("interval", 30*time.Second, "How often to read the clusters")
+}
+
+// Support functions to conduct the client call.
+
+// exporter holds the metrics last read from each cluster.
+type exporter struct {
+	mu       sync.Mutex
+	clusters []string
+	metrics  map[string]*client.Exposition
+}
+
+func runExporter(listen string, clusters []string, namespaces []string, interval time.Duration) error {
+	if interval <= 0 {
+		return usageError(fmt.Errorf("invalid --interval %s", interval))
+	}
+	if len(clusters) == 0 {
+		clusters = []string{cluster}
+	}
+	x := &exporter{clusters: clusters, metrics: make(map[string]*client.Exposition)}
+	clients := make(map[string]client.Interface)
+	for _, name := range clusters {
+		cl, err := clientFor(name)
+		if err != nil {
+			return err
+		}
+		clients[name] = cl
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var wg sync.WaitGroup
+	for _, name := range clusters {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			x.scrapeLoop(ctx, name, clients[name], namespaces, interval)
+		}(name)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", x.serveMetrics)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "k8ctl exporter: metrics are served on /metrics")
+	})
+	srv := &http.Server{Addr: listen, Handler: mux}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Fprintf(stderr, "Serving the metrics of %v on %s/metrics every %s.\n", clusters, listen, interval)
+
+	var err error
+	select {
+	case err = <-errc:
+		stop()
+		err = fmt.Errorf("cannot serve the metrics: %s", err)
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = srv.Shutdown(shutdown)
+		cancel()
+	}
+	wg.Wait()
+	return err
+}
+
+// scrapeLoop reads a cluster every interval until ctx is done. The resources the server does
+// not support are skipped.
+func (x *exporter) scrapeLoop(ctx context.Context, name string, cl client.Interface, namespaces []string,
+	interval time.Duration) {
+	var d *client.Discovery
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if d == nil || !d.Fresh(discoveryTTL) {
+			d, _ = cl.Discover() // Servers without a discovery route support everything.
+		}
+		var resources []string
+		for _, r := range client.ScrapeResources {
+			if d == nil || d.Supports(r, string(client.VerbList)) {
+				resources = append(resources, r)
+			}
+		}
+		e, errs := client.ScrapeMetrics(cl, name, namespaces, resources, time.Now())
+		for _, err := range errs {
+			fmt.Fprintf(stderr, "Warning: cannot read cluster %s: %s\n", name, err)
+		}
+		x.mu.Lock()
+		x.metrics[name] = e
+		x.mu.Unlock()
+		if err := tracer.Flush(); err != nil {
+			fmt.Fprintln(stderr, "Warning: cannot export the traces:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// serveMetrics answers the metrics last read from the clusters.
+func (x *exporter) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	e := client.NewExposition()
+	x.mu.Lock()
+	for _, name := range x.clusters {
+		e.Merge(x.metrics[name])
+	}
+	x.mu.Unlock()
+	w.Header().Set("Content-Type", client.ExpositionContentType)
+	e.WriteTo(w)
+}
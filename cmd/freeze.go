@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	freezeCmd = &cobra.Command{
+		Use:     "freeze",
+		Short:   "Display and manage release freezes",
+		Long:    "Top level command for freezing deploys, rollbacks, deletes and restarts in a namespace.",
+		Example: `k8ctl freeze --help (for subcommands)`,
+	}
+
+	freezeSubCmdClear = &cobra.Command{
+		Use:   "clear [flags]",
+		Short: "Clear the freeze of a namespace",
+		Long:  "Clear removes the freeze of a namespace on the server. Scheduled windows in the config are not affected.",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := cmd.Flags().GetString("namespace")
+			if err != nil {
+				return err
+			}
+			return runFreezeClear(namespace)
+		},
+		Example: `k8ctl freeze clear --help
+k8ctl freeze clear --cluster nyc --namespace prod`,
+	}
+
+	freezeSubCmdList = &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List freezes",
+		Long:  "List will display the freezes set on the server and the scheduled windows from the config in effect now.",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, format string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if format, err = cmd.Flags().GetString("format"); err != nil {
+				return err
+			}
+			return runFreezeList(namespace, format)
+		},
+		Example: `k8ctl freeze list --help
+k8ctl freeze list --cluster nyc
+k8ctl freeze list -l nyc -n prod --format json`,
+	}
+
+	freezeSubCmdSet = &cobra.Command{
+		Use:   "set [flags]",
+		Short: "Freeze a namespace",
+		Long: `Set freezes deploys, rollbacks, deletes and restarts in a namespace until a time.
+--until accepts a time (2020-12-24T00:00:00Z) or a duration from now (48h). A namespace of * freezes the cluster.`,
+		Args: cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, until, reason string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if until, err = cmd.Flags().GetString("until"); err != nil {
+				return err
+			}
+			if reason, err = cmd.Flags().GetString("reason"); err != nil {
+				return err
+			}
+			return runFreezeSet(namespace, until, reason)
+		},
+		Example: `k8ctl freeze set --help
+k8ctl freeze set --cluster nyc --namespace prod --until 2020-12-28T09:00:00-05:00 --reason "holiday freeze"
+k8ctl freeze set -l nyc -n prod --until 48h --reason "release week"`,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(freezeCmd)
+	freezeCmd.AddCommand(freezeSubCmdClear)
+	freezeCmd.AddCommand(freezeSubCmdList)
+	freezeCmd.AddCommand(freezeSubCmdSet)
+
+	freezeSubCmdClear.Flags().StringP("namespace", "n", "", "Namespace to unfreeze. (required)")
+	freezeSubCmdClear.MarkFlagRequired("namespace")
+
+	freezeSubCmdList.Flags().StringP("namespace", "n", "", "Namespace to report (optional: default is all)")
+	freezeSubCmdList.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+
+	freezeSubCmdSet.Flags().StringP("namespace", "n", "", "Namespace to freeze, or * for all. (required)")
+	freezeSubCmdSet.Flags().String("until", "", "When the freeze ends: a time or a duration from now. (required)")
+	freezeSubCmdSet.Flags().String("reason", "", "Why changes are frozen. (required)")
+	freezeSubCmdSet.MarkFlagRequired("namespace")
+	freezeSubCmdSet.MarkFlagRequired("until")
+	freezeSubCmdSet.MarkFlagRequired("reason")
+}
+
+// addOverrideFreezeFlag adds the flag to run a command during a freeze.
+func addOverrideFreezeFlag(cmd *cobra.Command) {
+	cmd.Flags().String("override-freeze", "", "Run during a freeze; the justification is added to the memo")
+}
+
+// activeFreezes returns the freezes in effect now from the server of a cluster and the config.
+// d is the capabilities of the server, nil if unknown.
+func activeFreezes(cl client.Interface, d *client.Discovery) ([]client.Freeze, error) {
+	freezes, err := serverFreezes(cl, d)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := windowFreezes()
+	if err != nil {
+		return nil, err
+	}
+	return append(freezes, windows...), nil
+}
+
+// serverFreezes returns the freezes in effect now from the server of a cluster. A server
+// without freezes has none.
+func serverFreezes(cl client.Interface, d *client.Discovery) ([]client.Freeze, error) {
+	if d != nil && !d.Supports("freezes", "") {
+		return nil, nil
+	}
+	freezes, err := cl.Freezes("")
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil // The server predates freezes.
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var result []client.Freeze
+	for _, f := range freezes {
+		if now.Before(f.Until) {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// windowFreezes returns the freezes in effect now from the freeze windows of the config.
+func windowFreezes() ([]client.Freeze, error) {
+	var windows []client.FreezeWindow
+	if err := viper.UnmarshalKey("freeze_windows", &windows); err != nil {
+		return nil, err
+	}
+	var result []client.Freeze
+	for _, w := range windows {
+		freezes, err := w.Active(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, freezes...)
+	}
+	return result, nil
+}
+
+// checkFreeze refuses a change to a namespace of the selected cluster during a freeze unless
+// overridden. It returns the text to add to the memo of an override.
+func checkFreeze(namespace string, release string, override string) (string, error) {
+	return checkFreezeIn(newClient(), discovery, cluster, namespace, release, override)
+}
+
+// checkFreezeIn refuses a change to a namespace of a cluster during a freeze unless overridden.
+// If the namespace is unknown, the namespace the release is deployed to is looked up when a
+// namespace is frozen. When the freezes of the server or the namespace of the release cannot be
+// retrieved, the change is refused too: it may be frozen. It returns the text to add to the
+// memo of an override.
+func checkFreezeIn(cl client.Interface, d *client.Discovery, clusterName string, namespace string, release string,
+	override string) (string, error) {
+	freezes, err := windowFreezes()
+	if err != nil {
+		return "", err
+	}
+	server, err := serverFreezes(cl, d)
+	if err != nil {
+		if override == "" {
+			return "", fmt.Errorf("cannot check the freezes of cluster %s: %w; use --override-freeze with a justification to proceed",
+				clusterName, err)
+		}
+		fmt.Fprintf(stderr, "Warning: overriding the unknown freezes of cluster %s: %s\n", clusterName, override)
+		return overrideNote(override), nil
+	}
+	freezes = append(server, freezes...)
+	now := time.Now()
+	if namespace == "" && namespaceFrozen(freezes, now) {
+		if namespace, err = releaseNamespace(cl, release); err != nil {
+			if override == "" {
+				return "", fmt.Errorf("cannot find the namespace of release %s of cluster %s to check its freezes: %w; use --namespace, or --override-freeze with a justification to proceed",
+					release, clusterName, err)
+			}
+			fmt.Fprintf(stderr, "Warning: overriding the unknown freezes of release %s: %s\n", release, override)
+			return overrideNote(override), nil
+		}
+	}
+	for _, f := range freezes {
+		if !f.Applies(namespace, now) {
+			continue
+		}
+		if override == "" {
+			return "", conflictError(fmt.Errorf("namespace %s of cluster %s is frozen until %s (%s: %s); use --override-freeze with a justification to proceed",
+				f.Namespace, clusterName, f.Until.Local().Format(time.RFC3339), f.Source, f.Reason))
+		}
+		fmt.Fprintf(stderr, "Warning: overriding the freeze of namespace %s: %s\n", f.Namespace, override)
+		return overrideNote(override), nil
+	}
+	return "", nil
+}
+
+// namespaceFrozen returns true if a freeze of a namespace, rather than of the cluster, is in
+// effect at time t.
+func namespaceFrozen(freezes []client.Freeze, t time.Time) bool {
+	for _, f := range freezes {
+		if f.Namespace != "*" && t.Before(f.Until) {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseNamespace returns the namespace a release is deployed to, empty if it does not exist.
+func releaseNamespace(cl client.Interface, release string) (string, error) {
+	releases, err := cl.Releases("")
+	if err != nil {
+		return "", err
+	}
+	for _, r := range releases {
+		if r.Name == release {
+			return r.Namespace, nil
+		}
+	}
+	return "", nil
+}
+
+// overrideNote returns the text added to the memo of a change made during a freeze.
+func overrideNote(override string) string {
+	return fmt.Sprintf("[freeze override by %s: %s]", currentUser(), override)
+}
+
+// withOverride appends the freeze override note to a memo.
+func withOverride(memo string, note string) string {
+	switch {
+	case note == "":
+		return memo
+	case memo == "":
+		return note
+	}
+	return fmt.Sprintf("%s %s", memo, note)
+}
+
+// Support functions to conduct the client call.
+
+func runFreezeClear(namespace string) error {
+	cl := newClient()
+	resp, err := audited(&operation{Event: "freeze", Namespace: namespace}, func() (*client.Response, error) {
+		return cl.ClearFreeze(namespace)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
+
+func runFreezeList(namespace string, format string) error {
+	freezes, err := activeFreezes(newClient(), discovery)
+	if err != nil {
+		return err
+	}
+	var selected []client.Freeze
+	for _, f := range freezes {
+		if namespace == "" || f.Namespace == namespace || f.Namespace == "*" {
+			selected = append(selected, f)
+		}
+	}
+	if format != "" {
+		return printObject(selected, format)
+	}
+	var rows [][]string
+	for _, f := range selected {
+		rows = append(rows, []string{f.Namespace, f.Until.Local().Format(time.RFC3339), f.Source, f.User, f.Reason})
+	}
+	printTable([]string{"NAMESPACE", "UNTIL", "SOURCE", "USER", "REASON"}, rows)
+	return nil
+}
+
+func runFreezeSet(namespace string, until string, reason string) error {
+	end, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		d, derr := time.ParseDuration(until)
+		if derr != nil {
+			return fmt.Errorf("invalid --until %q: use a time such as 2020-12-24T00:00:00Z or a duration such as 48h", until)
+		}
+		end = time.Now().Add(d)
+	}
+	cl := newClient()
+	resp, err := audited(&operation{Event: "freeze", Namespace: namespace, Memo: reason}, func() (*client.Response, error) {
+		return cl.SetFreeze(namespace, end, reason, currentUser())
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
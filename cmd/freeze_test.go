@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/composer22/k8ctl/client/fake"
+)
+
+func TestDeleteInFrozenNamespaceWithoutNamespace(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) {
+		// The release name does not end with its namespace.
+		m.Releases["billing"] = &fake.Release{Release: client.Release{Name: "billing", Namespace: "prod",
+			Status: client.ReleaseDeployed}}
+		m.SetFreeze("prod", time.Now().Add(time.Hour), "release week", "ops")
+	})
+	_, _, err := runCommand(t, s, "releases", "delete", "billing")
+	if ExitCode(err) != ExitConflict {
+		t.Errorf("delete in a frozen namespace: exit %d (%v), want %d", ExitCode(err), err, ExitConflict)
+	}
+	s.Update(func(m *fake.Model) {
+		if m.Releases["billing"] == nil {
+			t.Error("release deleted during the freeze")
+		}
+	})
+	if _, _, err := runCommand(t, s, "releases", "delete", "billing", "--override-freeze", "hotfix"); err != nil {
+		t.Errorf("delete with an override: %s", err)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-
-	"github.com/composer22/k8ctl/client"
 )
 
 // guideCmd returns extra help to the user
@@ -16,7 +14,8 @@ var guideCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return printGuide()
 	},
-	Example: `k8ctl guide`,
+	Example:     `k8ctl guide`,
+	Annotations: map[string]string{"resource": "guide"},
 }
 
 func init() {
@@ -25,11 +24,11 @@ func init() {
 
 // Prints out the response message with the guide.
 func printGuide() error {
-	cl := client.NewClient(clusterUrl, bearerToken)
+	cl := newClient()
 	resp, err := cl.Guide()
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp.Message)
+	fmt.Fprintln(stdout, resp.Message)
 	return nil
 }
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	healthCmd = &cobra.Command{
+		Use:   "health [flags]",
+		Short: "Check the health of a namespace",
+		Long: `Health checks a namespace and gives one verdict. It reports the releases failed or pending, the
+deployments with unavailable replicas, the pods not running, not ready or restarting more than
+--max-restarts, the failed jobs, the cronjobs that have not run when their schedule last fired
+(allowing for --cron-grace) and the services without endpoints.
+
+The checks the server does not support are skipped, and the services whose endpoints it does not
+report cannot be judged: the verdict is then unknown.
+
+It exits with 9 if a problem is found, with the code of the error if a check cannot run, and with 1
+if the verdict is otherwise unknown.`,
+		Args: cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, format string
+			var opts client.HealthOptions
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if format, err = cmd.Flags().GetString("format"); err != nil {
+				return err
+			}
+			if opts.MaxRestarts, err = cmd.Flags().GetInt("max-restarts"); err != nil {
+				return err
+			}
+			if opts.CronGrace, err = cmd.Flags().GetDuration("cron-grace"); err != nil {
+				return err
+			}
+			return runHealth(namespace, format, &opts)
+		},
+		Example: `k8ctl health --help
+k8ctl health --cluster nyc --namespace prod
+k8ctl health -l nyc -n prod --max-restarts 0
+k8ctl health -l nyc -n prod -f json`,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(healthCmd)
+
+	healthCmd.Flags().StringP("namespace", "n", "", "Namespace to check. (required)")
+	healthCmd.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+	healthCmd.Flags().Int("max-restarts", client.DefaultMaxRestarts, "Restarts of a pod beyond which it is reported")
+	healthCmd.Flags().Duration("cron-grace", client.DefaultCronGrace, "How late a cronjob may run before it is reported")
+	healthCmd.MarkFlagRequired("namespace")
+}
+
+// Support functions to conduct the client call.
+
+func runHealth(namespace string, format string, opts *client.HealthOptions) error {
+	d := loadDiscovery()
+	for _, check := range client.HealthChecks {
+		if d != nil && !d.Supports(check, string(client.VerbList)) {
+			opts.Skipped = append(opts.Skipped, check)
+		}
+	}
+	r := client.CheckHealth(newClient(), namespace, opts)
+	if format != "" {
+		if err := printObject(r, format); err != nil {
+			return err
+		}
+	} else {
+		printHealth(r)
+	}
+	switch r.Verdict {
+	case client.HealthUnhealthy:
+		return unhealthyError(errors.New(r.Summary()))
+	case client.HealthUnknown:
+		return healthCheckError(r)
+	}
+	return nil
+}
+
+// healthCheckError returns the error of the first check that could not run, or the summary if
+// the checks were skipped or resources could not be judged.
+func healthCheckError(r *client.HealthReport) error {
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			return fmt.Errorf("%s: %s: %w", r.Summary(), c.Check, c.Err)
+		}
+	}
+	return errors.New(r.Summary())
+}
+
+// printHealth prints the verdict of a health report, the outcome of each check and the problems.
+func printHealth(r *client.HealthReport) {
+	fmt.Fprintf(stdout, "%s/%s: %s\n\n", cluster, r.Namespace, strings.ToUpper(r.Verdict))
+	var rows [][]string
+	for _, c := range r.Checks {
+		result := "ok"
+		switch {
+		case c.Skipped:
+			result = "skipped: not supported by the server"
+		case c.Error != "":
+			result = "error: " + c.Error
+		case c.Problems > 0:
+			result = fmt.Sprintf("%d problem(s)", c.Problems)
+		case c.Unknown > 0:
+			result = fmt.Sprintf("%d unknown", c.Unknown)
+		}
+		rows = append(rows, []string{c.Check, strconv.Itoa(c.Checked), result})
+	}
+	printTable([]string{"CHECK", "CHECKED", "RESULT"}, rows)
+	printHealthProblems("PROBLEM", r.Problems)
+	printHealthProblems("UNKNOWN", r.Unknown)
+}
+
+// printHealthProblems prints the problems or unknowns of a health report, if any.
+func printHealthProblems(header string, problems []client.HealthProblem) {
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Fprintln(stdout)
+	var rows [][]string
+	for _, p := range problems {
+		rows = append(rows, []string{p.Check, p.Name, p.Reason})
+	}
+	printTable([]string{"CHECK", "NAME", header}, rows)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/viper"
+)
+
+// notifySinks returns the sinks configured under notifications in the config.
+func notifySinks() ([]client.Sink, error) {
+	var sinks []client.Sink
+	if err := viper.UnmarshalKey("notifications", &sinks); err != nil {
+		return nil, err
+	}
+	return sinks, nil
+}
+
+// notify sends the outcome of an operation to every sink that wants the event. Sinks are
+// posted to in parallel and failures are reported as warnings.
+func notify(op *operation, e *client.AuditEntry) {
+	sinks, err := notifySinks()
+	if err != nil {
+		fmt.Fprintln(stderr, "Warning: invalid notifications in the config:", err)
+		return
+	}
+	n := &client.Notification{
+		Event:     op.Event,
+		Cluster:   e.Cluster,
+		Namespace: op.Namespace,
+		Release:   op.Release,
+		Tag:       op.Tag,
+		User:      e.User,
+		Memo:      op.Memo,
+		Result:    e.Result,
+		Error:     e.Error,
+		Duration:  (time.Duration(e.DurationMs) * time.Millisecond).String(),
+		RequestID: e.RequestID,
+	}
+	var wg sync.WaitGroup
+	for i := range sinks {
+		s := &sinks[i]
+		if !s.Wants(op.Event) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Send(n); err != nil {
+				fmt.Fprintln(stderr, "Warning: cannot send notification:", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+// printObject prints data rendered by the client in the requested format (json|yaml).
+func printObject(v interface{}, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(b))
+	case "yaml":
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(stdout, string(b))
+	default:
+		return fmt.Errorf("unknown format %q (json|yaml)", format)
+	}
+	return nil
+}
+
+// printTable prints rows as aligned columns under a header.
+func printTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// ANSI colors of diff lines.
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// useColor returns true if output to stdout should be colorized: it is a terminal, NO_COLOR
+// is not set and color was not turned off.
+func useColor(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(stdout)
+}
+
+// isTerminal returns true if a stream is a file open on a terminal.
+func isTerminal(stream interface{}) bool {
+	f, ok := stream.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// printDiff prints a unified diff, colorizing removed, added and hunk lines.
+func printDiff(diff string, color bool) {
+	if !color {
+		fmt.Fprint(stdout, diff)
+		return
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++"):
+			fmt.Fprint(stdout, line)
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprint(stdout, colorRed+strings.TrimSuffix(line, "\n")+colorReset+"\n")
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprint(stdout, colorGreen+strings.TrimSuffix(line, "\n")+colorReset+"\n")
+		case strings.HasPrefix(line, "@@"):
+			fmt.Fprint(stdout, colorCyan+strings.TrimSuffix(line, "\n")+colorReset+"\n")
+		default:
+			fmt.Fprint(stdout, line)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var releasesSubCmdPlan = &cobra.Command{
+	Use:   "plan [flags] [CHART]",
+	Short: "Show what a deploy would change",
+	Long: `Plan asks the server to render a deploy of a tag without applying it and compares it, resource
+by resource, with what is deployed now. Changes of replicas, images, env and resource requests and
+limits are listed; --full adds the unified diff of each resource.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		release := args[0]
+		var tag, namespace, format string
+		var full, noColor bool
+		var err error
+		if tag, err = cmd.Flags().GetString("tag"); err != nil {
+			return err
+		}
+		if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+			return err
+		}
+		if full, err = cmd.Flags().GetBool("full"); err != nil {
+			return err
+		}
+		if format, err = cmd.Flags().GetString("format"); err != nil {
+			return err
+		}
+		if noColor, err = cmd.Flags().GetBool("no-color"); err != nil {
+			return err
+		}
+		_, err = runPlan(release, tag, namespace, full, format, noColor)
+		return err
+	},
+	Example: `k8ctl releases plan --help
+k8ctl releases plan --cluster nyc --namespace prod --tag k8-1.0.0-1234 myapp-service
+k8ctl releases plan -l nyc -n prod -t k8-1.0.0-1234 --full myapp-service
+k8ctl releases plan -l nyc -n prod -t k8-1.0.0-1234 --format json myapp-service`,
+}
+
+func init() {
+	releasesCmd.AddCommand(releasesSubCmdPlan)
+	releasesSubCmdPlan.Flags().StringP("tag", "t", "", "Docker image tag (required)")
+	releasesSubCmdPlan.Flags().StringP("namespace", "n", "", "Namespace to deploy to: dev, qa etc. (required)")
+	releasesSubCmdPlan.Flags().Bool("full", false, "Show the unified diff of each changed resource")
+	releasesSubCmdPlan.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+	releasesSubCmdPlan.Flags().Bool("no-color", false, "Do not colorize the diff")
+	releasesSubCmdPlan.MarkFlagRequired("tag")
+	releasesSubCmdPlan.MarkFlagRequired("namespace")
+}
+
+// Support functions to conduct the client call.
+
+// runPlan prints the changes a deploy would make and returns how many resources change.
+func runPlan(release string, tag string, namespace string, full bool, format string, noColor bool) (int, error) {
+	d, err := newClient().DeployDryRun(release, tag, namespace)
+	if err != nil {
+		return 0, err
+	}
+	changes, err := client.DiffManifests(d.Current, d.Planned)
+	if err != nil {
+		return 0, err
+	}
+	if format != "" {
+		if changes == nil {
+			changes = []client.ResourceChange{}
+		}
+		if !full {
+			for i := range changes {
+				changes[i].Diff = ""
+			}
+		}
+		return len(changes), printObject(changes, format)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "No changes.")
+		return 0, nil
+	}
+	color := useColor(noColor)
+	for _, c := range changes {
+		sign, paint := "~", ""
+		switch c.Action {
+		case client.ChangeAdded:
+			sign, paint = "+", colorGreen
+		case client.ChangeRemoved:
+			sign, paint = "-", colorRed
+		}
+		header := fmt.Sprintf("%s %s/%s (%s)", sign, c.Kind, c.Name, c.Action)
+		if color && paint != "" {
+			header = paint + header + colorReset
+		}
+		fmt.Fprintln(stdout, header)
+		for _, line := range c.Changes {
+			fmt.Fprintf(stdout, "    %s\n", line)
+		}
+		if c.Action == client.ChangeChanged && len(c.Changes) == 0 && !full {
+			fmt.Fprintln(stdout, "    other fields changed (use --full to see them)")
+		}
+		if full {
+			printDiff(c.Diff, color)
+		}
+	}
+	fmt.Fprintf(stdout, "%d resource(s) of %s would change in %s/%s.\n", len(changes), release, cluster, namespace)
+	return len(changes), nil
+}
+
+// confirmPlan shows what a deploy would change and asks whether to apply it. When the input is
+// a file but not a terminal the deploy must be confirmed with --yes.
+func confirmPlan(release string, tag string, namespace string, yes bool) (bool, error) {
+	n, err := runPlan(release, tag, namespace, false, "", false)
+	if err != nil || n == 0 || yes {
+		return n > 0, err
+	}
+	if _, ok := stdin.(*os.File); ok && !isTerminal(stdin) {
+		return false, errors.New("not deploying: confirm the changes with --yes")
+	}
+	fmt.Fprintf(stdout, "Deploy these changes to %s/%s? [y/N] ", cluster, namespace)
+	answer, _ := bufio.NewReader(stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		fmt.Fprintln(stdout, "Not deployed.")
+		return false, nil
+	}
+	return true, nil
+}
@@ -2,17 +2,26 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/composer22/k8ctl/client"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	historyStatsWindow = 30 * 24 * time.Hour // Default window of releases history --stats.
 )
 
 var (
 	releasesCmd = &cobra.Command{
-		Use:     "releases",
-		Short:   "Display and manage helm releases",
-		Long:    "Top level command for displaying and managing helm releases",
-		Example: `k8ctl releases --help (for subcommands)`,
+		Use:         "releases",
+		Short:       "Display and manage helm releases",
+		Long:        "Top level command for displaying and managing helm releases",
+		Example:     `k8ctl releases --help (for subcommands)`,
+		Annotations: map[string]string{"resource": "releases"},
 	}
 
 	releasesSubCmdDelete = &cobra.Command{
@@ -22,7 +31,15 @@ var (
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			release := args[0]
-			return runDelete(release)
+			var namespace, override string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if override, err = cmd.Flags().GetString("override-freeze"); err != nil {
+				return err
+			}
+			return runDelete(release, namespace, override)
 		},
 		Example: `k8ctl releases delete --help
 k8ctl releases delete --cluster nyc myapp-dev
@@ -32,11 +49,17 @@ k8ctl releases delete -l nyc myapp-dev`,
 	releasesSubCmdDeploy = &cobra.Command{
 		Use:   "deploy [flags] [CHART]",
 		Short: "Deploy or refreshes a release",
-		Long:  "Deploy will apply a helm chart into a namespace.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Deploy will apply a helm chart into a namespace. By default all pods are upgraded at once.
+With --strategy canary the new version receives the percent of traffic of each of --steps in turn,
+pausing between steps; with --strategy bluegreen it runs beside the old version until healthy and
+then receives all traffic. A rollout whose health checks fail is aborted automatically.
+With --diff the changes are shown first, as by "releases plan", and applied once confirmed.
+Deploys to namespaces for which the server requires approval wait on the server for a second
+person to approve them; --wait-for-approval waits for the review.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			release := args[0]
-			var tag, namespace, memo string
+			var tag, namespace, memo, override string
 			var err error
 			if tag, err = cmd.Flags().GetString("tag"); err != nil {
 				return err
@@ -47,31 +70,85 @@ k8ctl releases delete -l nyc myapp-dev`,
 			if memo, err = cmd.Flags().GetString("memo"); err != nil {
 				return err
 			}
+			if override, err = cmd.Flags().GetString("override-freeze"); err != nil {
+				return err
+			}
+			var opts *rolloutOptions
+			if opts, err = rolloutFlags(cmd); err != nil {
+				return err
+			}
+			var approval *approvalOptions
+			if approval, err = approvalFlags(cmd); err != nil {
+				return err
+			}
+			var diff, yes bool
+			if diff, err = cmd.Flags().GetBool("diff"); err != nil {
+				return err
+			}
+			if yes, err = cmd.Flags().GetBool("yes"); err != nil {
+				return err
+			}
 
-			return runDeploy(release, tag, namespace, memo)
+			return runDeploy(release, tag, namespace, memo, override, diff, yes, opts, approval)
 		},
 		Example: `k8ctl releases deploy --help
 k8ctl releases deploy --cluster nyc --namespace dev --tag k8-1.0.0-1234 -m "a boring bug." myapp-service
-k8ctl releases deploy -l nyc -n dev -t k8-1.0.0-1234 --memo "a really good bug!" myapp-service`,
+k8ctl releases deploy -l nyc -n dev -t k8-1.0.0-1234 --memo "a really good bug!" myapp-service
+k8ctl releases deploy -l nyc -n prod -t k8-1.0.0-1234 -m "reviewed" --diff myapp-service
+k8ctl releases deploy -l nyc -n prod -t k8-1.0.0-1234 -m "hotfix" --wait-for-approval myapp-service
+k8ctl releases deploy -l nyc -n prod -t k8-1.0.0-1234 -m "new search" --strategy canary --steps 10,25,50,100 --pause 5m myapp-service
+k8ctl releases deploy -l nyc -n prod -t k8-1.0.0-1234 -m "new search" --strategy bluegreen myapp-service`,
 	}
 
 	releasesSubCmdHistory = &cobra.Command{
-		Use:   "history [flags] [RELEASE]",
+		Use:   "history [flags] [RELEASE...]",
 		Short: "Display release history",
-		Long:  "Displays the history of a release including previous releases and failed deploys.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Displays the history of a release including previous releases and failed deploys.
+With --stats it reports the deploy frequency, failure rate, mean time between deploys and rollback
+count of one or more releases over the --since window (default 30 days).`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			release := args[0]
-			format, err := cmd.Flags().GetString("format")
-			if err != nil {
+			var f client.HistoryFilter
+			var since time.Duration
+			var format string
+			var stats bool
+			var err error
+			if format, err = cmd.Flags().GetString("format"); err != nil {
 				return err
 			}
-			return runHistory(release, format)
+			if since, err = cmd.Flags().GetDuration("since"); err != nil {
+				return err
+			}
+			if f.Status, err = cmd.Flags().GetString("status"); err != nil {
+				return err
+			}
+			if f.Limit, err = cmd.Flags().GetInt("limit"); err != nil {
+				return err
+			}
+			if stats, err = cmd.Flags().GetBool("stats"); err != nil {
+				return err
+			}
+			if stats {
+				if since == 0 {
+					since = historyStatsWindow
+				}
+				return runHistoryStats(args, time.Now().Add(-since), format)
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("history accepts one release unless --stats is given")
+			}
+			if since > 0 {
+				f.Since = time.Now().Add(-since)
+			}
+			return runHistory(args[0], &f, format)
 		},
 		Example: `k8ctl releases history --help
 k8ctl releases history --cluster nyc my-release-dev
 k8ctl releases history -l nyc --format json my-release-dev
-k8ctl releases history -l nyc -f yaml my-release-dev`,
+k8ctl releases history -l nyc -f yaml my-release-dev
+k8ctl releases history -l nyc --since 168h --status failed my-release-dev
+k8ctl releases history -l nyc --limit 5 my-release-dev
+k8ctl releases history -l nyc --stats --since 720h my-release-dev other-release-dev`,
 	}
 
 	releasesSubCmdList = &cobra.Command{
@@ -98,6 +175,52 @@ k8ctl release list -l nyc -n dev --format json
 k8ctl release list -l nyc -n dev -f yaml`,
 	}
 
+	releasesSubCmdPromote = &cobra.Command{
+		Use:   "promote [flags] [CHART]",
+		Short: "Promote a release to the next namespace",
+		Long: `Promote reads the version tag deployed in one namespace and deploys it to another,
+optionally in another cluster. If promotion_path is set in the config, the target must be
+the next step after the source.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			release := args[0]
+			var from, to, toCluster, memo, override string
+			var wait bool
+			var timeout time.Duration
+			var err error
+			if from, err = cmd.Flags().GetString("from"); err != nil {
+				return err
+			}
+			if to, err = cmd.Flags().GetString("to"); err != nil {
+				return err
+			}
+			if toCluster, err = cmd.Flags().GetString("to-cluster"); err != nil {
+				return err
+			}
+			if memo, err = cmd.Flags().GetString("memo"); err != nil {
+				return err
+			}
+			if wait, err = cmd.Flags().GetBool("wait"); err != nil {
+				return err
+			}
+			if timeout, err = cmd.Flags().GetDuration("timeout"); err != nil {
+				return err
+			}
+			if override, err = cmd.Flags().GetString("override-freeze"); err != nil {
+				return err
+			}
+			var approval *approvalOptions
+			if approval, err = approvalFlags(cmd); err != nil {
+				return err
+			}
+			return runPromote(release, from, to, toCluster, memo, override, wait, timeout, approval)
+		},
+		Example: `k8ctl releases promote --help
+k8ctl releases promote --cluster nyc --from dev --to qa myapp-service
+k8ctl releases promote -l nyc --from staging --to prod --to-cluster boston --wait myapp-service
+k8ctl releases promote -l nyc --from qa --to staging -m "sprint 42" --wait --timeout 10m myapp-service`,
+	}
+
 	releasesSubCmdRollback = &cobra.Command{
 		Use:   "rollback [flags] [RELEASE]",
 		Short: "Rollback a release",
@@ -105,11 +228,18 @@ k8ctl release list -l nyc -n dev -f yaml`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			release := args[0]
-			revision, err := cmd.Flags().GetString("revision")
-			if err != nil {
+			var revision, namespace, override string
+			var err error
+			if revision, err = cmd.Flags().GetString("revision"); err != nil {
+				return err
+			}
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if override, err = cmd.Flags().GetString("override-freeze"); err != nil {
 				return err
 			}
-			return runRollback(release, revision)
+			return runRollback(release, revision, namespace, override)
 		},
 		Example: `k8ctl releases rollback --help
 k8ctl releases rollback --cluster nyc my-release-dev-003
@@ -144,85 +274,305 @@ func init() {
 	releasesCmd.AddCommand(releasesSubCmdDeploy)
 	releasesCmd.AddCommand(releasesSubCmdHistory)
 	releasesCmd.AddCommand(releasesSubCmdList)
+	releasesCmd.AddCommand(releasesSubCmdPromote)
 	releasesCmd.AddCommand(releasesSubCmdRollback)
 	releasesCmd.AddCommand(releasesSubCmdStatus)
 
+	releasesSubCmdDelete.Flags().StringP("namespace", "n", "", "Namespace of the release, used to check freezes (optional: default is looked up)")
+	addOverrideFreezeFlag(releasesSubCmdDelete)
+
 	releasesSubCmdDeploy.Flags().StringP("tag", "t", "", "Docker image tag (required)")
 	releasesSubCmdDeploy.Flags().StringP("namespace", "n", "", "Namespace to deploy to: dev, qa etc. (required)")
 	releasesSubCmdDeploy.Flags().StringP("memo", "m", "", "Information to display in slack etc. (required)")
 	releasesSubCmdDeploy.MarkFlagRequired("tag")
 	releasesSubCmdDeploy.MarkFlagRequired("namespace")
 	releasesSubCmdDeploy.MarkFlagRequired("memo")
+	addOverrideFreezeFlag(releasesSubCmdDeploy)
+	addRolloutFlags(releasesSubCmdDeploy)
+	releasesSubCmdDeploy.Flags().Bool("diff", false, "Show what the deploy would change and ask before applying it")
+	releasesSubCmdDeploy.Flags().Bool("yes", false, "Apply the changes shown by --diff without asking")
+	addApprovalFlags(releasesSubCmdDeploy)
 
 	releasesSubCmdHistory.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+	releasesSubCmdHistory.Flags().Duration("since", 0, "Only revisions newer than this, ex: 168h (optional)")
+	releasesSubCmdHistory.Flags().String("status", "", "Only revisions with this status, ex: failed (optional)")
+	releasesSubCmdHistory.Flags().Int("limit", 0, "Only the most recent revisions (optional)")
+	releasesSubCmdHistory.Flags().Bool("stats", false, "Report deploy statistics instead of revisions")
 
 	releasesSubCmdList.Flags().StringP("namespace", "n", "", "Namespace to list to: dev, qa etc. (required)")
 	releasesSubCmdList.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
 	releasesSubCmdList.MarkFlagRequired("namespace")
 
+	releasesSubCmdPromote.Flags().String("from", "", "Namespace to promote from: dev, qa etc. (required)")
+	releasesSubCmdPromote.Flags().String("to", "", "Namespace to promote to: qa, staging etc. (required)")
+	releasesSubCmdPromote.Flags().String("to-cluster", "", "Cluster to promote to (optional: default is --cluster)")
+	releasesSubCmdPromote.Flags().StringP("memo", "m", "", "Information added to the generated memo (optional)")
+	releasesSubCmdPromote.Flags().Bool("wait", false, "Wait for the release to become healthy on the target, and for approval if required")
+	releasesSubCmdPromote.Flags().Duration("timeout", 5*time.Minute, "How long to wait for the release to become healthy")
+	releasesSubCmdPromote.MarkFlagRequired("from")
+	releasesSubCmdPromote.MarkFlagRequired("to")
+	addOverrideFreezeFlag(releasesSubCmdPromote)
+	addApprovalFlags(releasesSubCmdPromote)
+
 	releasesSubCmdRollback.Flags().StringP("revision", "r", "0", "A previous release version")
+	releasesSubCmdRollback.Flags().StringP("namespace", "n", "", "Namespace of the release, used to check freezes (optional: default is looked up)")
+	addOverrideFreezeFlag(releasesSubCmdRollback)
 
 	releasesSubCmdStatus.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
 }
 
 // Support functions to conduct the client call.
 
-func runDelete(release string) error {
-	cl := client.NewClient(clusterUrl, bearerToken)
-	resp, err := cl.Delete(release)
+func runDelete(release string, namespace string, override string) error {
+	note, err := checkFreeze(namespace, release, override)
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp.Message)
+	cl := newClient()
+	resp, err := audited(&operation{Event: "delete", Namespace: namespace, Release: release, Memo: note}, func() (*client.Response, error) {
+		return cl.DeleteWithMemo(release, note)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
 	return nil
 }
 
-func runDeploy(release string, tag string, namespace string, memo string) error {
-	cl := client.NewClient(clusterUrl, bearerToken)
-	resp, err := cl.Deploy(release, tag, namespace, memo)
+func runDeploy(release string, tag string, namespace string, memo string, override string, diff bool, yes bool,
+	opts *rolloutOptions, approval *approvalOptions) error {
+	note, err := checkFreeze(namespace, release, override)
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp.Message)
+	cl := newClient()
+	d, err := discoveryOf(cl, cluster)
+	if err != nil {
+		return err
+	}
+	if d.RequiresApproval(namespace) {
+		if opts.Strategy != "" {
+			return fmt.Errorf("namespace %s requires approval; --strategy is not supported for approved deploys", namespace)
+		}
+		if diff {
+			// The reviewer applies the deploy: show the changes without asking.
+			if n, err := runPlan(release, tag, namespace, false, "", false); err != nil || n == 0 {
+				return err
+			}
+		}
+		return requestApproval(cl, cluster, release, tag, namespace, withOverride(memo, note), approval)
+	}
+	if diff {
+		if proceed, err := confirmPlan(release, tag, namespace, yes); !proceed {
+			return err
+		}
+	}
+	if opts.Strategy != "" {
+		return runRollout(release, tag, namespace, withOverride(memo, note), opts)
+	}
+	resp, err := audited(&operation{Event: "deploy", Namespace: namespace, Release: release, Tag: tag,
+		Memo: withOverride(memo, note)}, func() (*client.Response, error) {
+		return cl.Deploy(release, tag, namespace, withOverride(memo, note))
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
 	return nil
 }
 
-func runHistory(release string, format string) error {
-	cl := client.NewClient(clusterUrl, bearerToken)
-	resp, err := cl.History(release, format)
+func runHistory(release string, f *client.HistoryFilter, format string) error {
+	entries, err := newClient().ReleaseHistory(release)
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp.Message)
+	return printHistory(f.Apply(entries), format)
+}
+
+// printHistory prints the revisions of a release as a table, or in a format.
+func printHistory(entries []client.HistoryEntry, format string) error {
+	if format != "" {
+		return printObject(entries, format)
+	}
+	var rows [][]string
+	for _, h := range entries {
+		rows = append(rows, []string{strconv.Itoa(h.Revision), h.Updated.Local().Format(time.RFC3339), h.Status, h.Chart,
+			h.AppVersion, h.Deployer, h.Description})
+	}
+	printTable([]string{"REVISION", "UPDATED", "STATUS", "CHART", "APP VERSION", "DEPLOYER", "DESCRIPTION"}, rows)
+	return nil
+}
+
+func runHistoryStats(releases []string, since time.Time, format string) error {
+	cl := newClient()
+	now := time.Now()
+	var stats []*client.HistoryStats
+	for _, release := range releases {
+		entries, err := cl.ReleaseHistory(release)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, client.ComputeHistoryStats(release, entries, since, now))
+	}
+	if format != "" {
+		return printObject(stats, format)
+	}
+	var rows [][]string
+	for _, s := range stats {
+		mtbd, last := "-", "-"
+		if s.MeanSecondsBetween > 0 {
+			mtbd = (time.Duration(s.MeanSecondsBetween) * time.Second).Round(time.Minute).String()
+		}
+		if !s.LastDeploy.IsZero() {
+			last = s.LastDeploy.Local().Format(time.RFC3339)
+		}
+		rows = append(rows, []string{s.Release, strconv.Itoa(s.Deploys), fmt.Sprintf("%.1f", s.DeploysPerWeek),
+			strconv.Itoa(s.Failures), fmt.Sprintf("%.0f%%", s.FailureRate*100), mtbd, strconv.Itoa(s.Rollbacks), last})
+	}
+	fmt.Fprintf(stdout, "Since %s\n", since.Local().Format(time.RFC3339))
+	printTable([]string{"RELEASE", "DEPLOYS", "PER WEEK", "FAILURES", "FAILURE RATE", "MEAN TIME BETWEEN", "ROLLBACKS",
+		"LAST DEPLOY"}, rows)
 	return nil
 }
 
 func runList(namespace string, format string) error {
-	cl := client.NewClient(clusterUrl, bearerToken)
+	cl := newClient()
 	resp, err := cl.List(namespace, format)
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp.Message)
+	fmt.Fprintln(stdout, resp.Message)
 	return nil
 }
 
-func runRollback(release string, revision string) error {
-	cl := client.NewClient(clusterUrl, bearerToken)
-	resp, err := cl.Rollback(release, revision)
+func runPromote(release string, from string, to string, toCluster string, memo string, override string, wait bool,
+	timeout time.Duration, approval *approvalOptions) error {
+	if toCluster == "" {
+		toCluster = cluster
+	}
+	if err := checkPromotionPath(cluster, from, toCluster, to); err != nil {
+		return err
+	}
+	target, err := clientFor(toCluster)
+	if err != nil {
+		return err
+	}
+	d, err := discoveryOf(target, toCluster)
+	if err != nil {
+		return err
+	}
+	note, err := checkFreezeIn(target, d, toCluster, to, release, override)
+	if err != nil {
+		return err
+	}
+
+	// Read the tag deployed in the source.
+	src, err := newClient().FindRelease(release, from)
+	if err != nil {
+		return err
+	}
+	if src.Status != client.ReleaseDeployed {
+		return fmt.Errorf("release %s in %s/%s is %s, not %s", src.Name, cluster, from, src.Status, client.ReleaseDeployed)
+	}
+
+	// Deploy it to the target.
+	generated := fmt.Sprintf("Promoted %s %s from %s/%s to %s/%s by %s.", release, src.VersionTag, cluster, from,
+		toCluster, to, currentUser())
+	if memo != "" {
+		generated = fmt.Sprintf("%s %s", generated, memo)
+	}
+	if d.RequiresApproval(to) {
+		// The release cannot become healthy before the deploy is approved, so --wait waits for both.
+		approval.Wait = approval.Wait || wait
+		err := requestApproval(target, toCluster, release, src.VersionTag, to, withOverride(generated, note), approval)
+		if err != nil || !wait {
+			return err
+		}
+		return waitForPromotion(target, toCluster, release, to, src.VersionTag, timeout)
+	}
+
+	// With --wait, the promotion is recorded and notified once the release is healthy.
+	op := &operation{Event: "promote", Cluster: toCluster, Namespace: to, Release: release, Tag: src.VersionTag,
+		Memo: withOverride(generated, note)}
+	start := time.Now()
+	resp, err := target.Deploy(release, src.VersionTag, to, withOverride(generated, note))
+	if err == nil {
+		fmt.Fprintln(stdout, resp.Message)
+		if wait {
+			err = waitForPromotion(target, toCluster, release, to, src.VersionTag, timeout)
+		}
+	}
+	record(op, auditEntry(op, start, resp, err))
+	return err
+}
+
+// waitForPromotion waits for a promoted release to be deployed with its tag.
+func waitForPromotion(target client.Interface, toCluster string, release string, to string, tag string,
+	timeout time.Duration) error {
+	r, err := target.WaitForRelease(release, to, tag, timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Release %s revision %d is %s in %s/%s with tag %s\n", r.Name, r.Revision, r.Status, toCluster, to,
+		r.VersionTag)
+	return nil
+}
+
+// checkPromotionPath enforces the order of promotion_path from the config, if any. Steps are
+// namespaces, or cluster/namespace when a step is specific to a cluster. The target must be the
+// step after the source.
+func checkPromotionPath(fromCluster string, from string, toCluster string, to string) error {
+	path := viper.GetStringSlice("promotion_path")
+	if len(path) == 0 {
+		return nil
+	}
+	step := func(c string, ns string) int {
+		for i, s := range path {
+			if s == fmt.Sprintf("%s/%s", c, ns) {
+				return i
+			}
+		}
+		for i, s := range path {
+			if s == ns {
+				return i
+			}
+		}
+		return -1
+	}
+	i, j := step(fromCluster, from), step(toCluster, to)
+	if i < 0 {
+		return fmt.Errorf("%s/%s is not in the promotion path %s", fromCluster, from, strings.Join(path, " -> "))
+	}
+	if j != i+1 {
+		return fmt.Errorf("%s/%s is not the next step after %s/%s in the promotion path %s", toCluster, to, fromCluster,
+			from, strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+func runRollback(release string, revision string, namespace string, override string) error {
+	note, err := checkFreeze(namespace, release, override)
+	if err != nil {
+		return err
+	}
+	cl := newClient()
+	resp, err := audited(&operation{Event: "rollback", Namespace: namespace, Release: release, Tag: "revision " + revision, Memo: note},
+		func() (*client.Response, error) {
+			return cl.RollbackWithMemo(release, revision, note)
+		})
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp.Message)
+	fmt.Fprintln(stdout, resp.Message)
 	return nil
 }
 
 func runStatus(release string, format string) error {
-	cl := client.NewClient(clusterUrl, bearerToken)
+	cl := newClient()
 	resp, err := cl.Status(release, format)
 	if err != nil {
 		return err
 	}
-	fmt.Println(resp.Message)
+	fmt.Fprintln(stdout, resp.Message)
 	return nil
 }
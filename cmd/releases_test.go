@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/composer22/k8ctl/client/fake"
+)
+
+func TestDeployAndList(t *testing.T) {
+	s := newTestServer(t)
+	out, _, err := runCommand(t, s, "releases", "deploy", "myapp", "-n", "dev", "-t", "k8-1.0.0-1", "-m", "first")
+	if err != nil {
+		t.Fatalf("deploy: %s", err)
+	}
+	if !strings.Contains(out, "Release myapp-dev revision 1 of k8-1.0.0-1 is deployed") {
+		t.Errorf("deploy printed %q", out)
+	}
+	out, _, err = runCommand(t, s, "releases", "list", "-n", "dev")
+	if err != nil {
+		t.Fatalf("list: %s", err)
+	}
+	if !strings.Contains(out, "myapp-dev") {
+		t.Errorf("list printed %q, want myapp-dev", out)
+	}
+}
+
+func TestHistoryNotFound(t *testing.T) {
+	s := newTestServer(t)
+	_, _, err := runCommand(t, s, "releases", "history", "nope-dev")
+	if ExitCode(err) != ExitNotFound {
+		t.Errorf("history of a missing release: exit %d (%v), want %d", ExitCode(err), err, ExitNotFound)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) { m.Deploy("myapp", "dev", "k8-1.0.0-1", "") })
+	out, _, err := runCommand(t, s, "releases", "plan", "myapp", "-n", "dev", "-t", "k8-1.0.0-1")
+	if err != nil || out != "No changes.\n" {
+		t.Errorf("plan of the deployed tag = %q, %v, want No changes.", out, err)
+	}
+	out, _, err = runCommand(t, s, "releases", "plan", "myapp", "-n", "dev", "-t", "k8-1.0.0-2")
+	if err != nil || !strings.Contains(out, "k8-1.0.0-1 -> ") || !strings.Contains(out, "k8-1.0.0-2") {
+		t.Errorf("plan of a new tag = %q, %v, want the image change", out, err)
+	}
+}
+
+func TestDeployRequiringApproval(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) {
+		m.ApprovalNamespaces = []string{"prod"}
+		m.Deploy("myapp", "prod", "k8-1.0.0-1", "")
+	})
+	out, _, err := runCommand(t, s, "releases", "deploy", "myapp", "-n", "prod", "-t", "k8-1.0.0-2", "-m", "fix")
+	if err != nil {
+		t.Fatalf("deploy: %s", err)
+	}
+	if !strings.Contains(out, "requires approval") {
+		t.Errorf("deploy printed %q, want the approval request", out)
+	}
+	var approvals []*client.Approval
+	s.Update(func(m *fake.Model) {
+		approvals = m.Approvals
+		if rel := m.Releases["myapp-prod"]; rel.VersionTag != "k8-1.0.0-1" {
+			t.Errorf("release is at %s before approval", rel.VersionTag)
+		}
+	})
+	if len(approvals) != 1 || approvals[0].Status != client.ApprovalPending {
+		t.Fatalf("approvals = %+v, want one pending", approvals)
+	}
+	if _, _, err := runCommand(t, s, "approvals", "approve", approvals[0].ID); ExitCode(err) != ExitAuth {
+		t.Errorf("approval by the requester: exit %d (%v), want %d", ExitCode(err), err, ExitAuth)
+	}
+}
+
+func TestDeployDiffChecksBeforeAsking(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) {
+		m.ApprovalNamespaces = []string{"prod"}
+		m.Deploy("myapp", "prod", "k8-1.0.0-1", "")
+		m.Deploy("myapp", "qa", "k8-1.0.0-1", "")
+		m.SetFreeze("qa", time.Now().Add(time.Hour), "release week", "ops")
+	})
+	out, _, err := runCommand(t, s, "releases", "deploy", "myapp", "-n", "qa", "-t", "k8-1.0.0-2", "-m", "fix", "--diff")
+	if ExitCode(err) != ExitConflict || strings.Contains(out, "Deploy these changes") {
+		t.Errorf("deploy --diff in a frozen namespace = %q, exit %d (%v), want a conflict before asking", out,
+			ExitCode(err), err)
+	}
+	out, _, err = runCommand(t, s, "releases", "deploy", "myapp", "-n", "prod", "-t", "k8-1.0.0-2", "-m", "fix", "--diff")
+	if err != nil {
+		t.Fatalf("deploy --diff requiring approval: %s", err)
+	}
+	if strings.Contains(out, "Deploy these changes") || !strings.Contains(out, "k8-1.0.0-2") ||
+		!strings.Contains(out, "requires approval") {
+		t.Errorf("deploy --diff requiring approval printed %q, want the plan and the request, without asking", out)
+	}
+}
+
+func TestPromoteWaitRecordsHealth(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) {
+		m.Deploy("myapp", "qa", "k8-1.0.0-1", "")
+		m.FailingTags["k8-1.0.0-1"] = true // The deploy to prod does not become healthy.
+	})
+	_, _, err := runCommand(t, s, "releases", "promote", "myapp", "--from", "qa", "--to", "prod", "--wait",
+		"--timeout", "5s")
+	if err == nil {
+		t.Fatal("promote --wait of a release that fails succeeded")
+	}
+	out, _, err := runCommand(t, s, "audit", "--command", "promote", "--format", "json")
+	if err != nil {
+		t.Fatalf("audit: %s", err)
+	}
+	var entries []client.AuditEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("audit printed %q: %s", out, err)
+	}
+	if len(entries) != 1 || entries[0].Result != client.AuditFailure {
+		t.Errorf("audit entries = %+v, want one failure", entries)
+	}
+}
+
+func TestPromoteWaitRequiringApproval(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) {
+		m.ApprovalNamespaces = []string{"prod"}
+		m.Deploy("myapp", "qa", "k8-1.0.0-1", "")
+	})
+	// Reviewed as soon as it is polled.
+	s.Fail(fake.Failure{Method: "GET", Path: "/approvals/", Status: 200,
+		Body: `{"status":"OK","message":"{\"id\":\"a1\",\"status\":\"rejected\",\"reviewer\":\"ops\",\"reason\":\"not today\"}"}`})
+	out, _, err := runCommand(t, s, "releases", "promote", "myapp", "--from", "qa", "--to", "prod", "--wait",
+		"--approval-timeout", "1s")
+	if !strings.Contains(out, "Waiting up to") || err == nil || !strings.Contains(err.Error(), "rejected by ops") {
+		t.Errorf("promote --wait requiring approval = %q, %v, want a wait for approval", out, err)
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+// resourceCmds holds the top level command generated for each resource kind.
+var resourceCmds = map[string]*cobra.Command{}
+
+func init() {
+	for _, k := range client.Resources() {
+		RootCmd.AddCommand(resourceCommand(k))
+	}
+}
+
+// AddResource registers an extra resource kind served by the server and adds its commands
+// to the command tree. Programs embedding the command tree call this before Execute.
+func AddResource(k client.ResourceKind) error {
+	if err := client.RegisterResource(k); err != nil {
+		return err
+	}
+	RootCmd.AddCommand(resourceCommand(k))
+	return nil
+}
+
+// resourceCommand returns the top level command for a resource kind along with its
+// subcommands, generating it on first use.
+func resourceCommand(k client.ResourceKind) *cobra.Command {
+	if c, ok := resourceCmds[k.Name]; ok {
+		return c
+	}
+	name := k.CommandName()
+	short := fmt.Sprintf("Display %s information", k.Singular)
+	long := fmt.Sprintf("Top level command for displaying %s information in a namespace.", k.Singular)
+	if k.Supports(client.VerbRestart) {
+		short = fmt.Sprintf("Display and restart %s", pluralize(k.Singular))
+		long = fmt.Sprintf("Top level command for displaying or restarting a %s in a namespace.", k.Singular)
+	}
+	if k.Description != "" {
+		long = k.Description
+	}
+	c := &cobra.Command{
+		Use:         name,
+		Aliases:     k.Aliases,
+		Short:       short,
+		Long:        long,
+		Example:     fmt.Sprintf("k8ctl %s --help (for subcommands)", name),
+		Annotations: map[string]string{"resource": k.Name},
+	}
+	if k.Supports(client.VerbDescribe) {
+		c.AddCommand(resourceDescribeCommand(k))
+	}
+	if k.Supports(client.VerbList) {
+		c.AddCommand(resourceListCommand(k))
+	}
+	if k.Supports(client.VerbRestart) {
+		c.AddCommand(resourceRestartCommand(k))
+	}
+	resourceCmds[k.Name] = c
+	return c
+}
+
+// pluralize returns the plural of a singular resource name for help text.
+func pluralize(singular string) string {
+	if strings.HasSuffix(singular, "s") {
+		return singular + "es"
+	}
+	return singular + "s"
+}
+
+// argName returns the name of a resource argument for usage text, ex: POD-DISRUPTION-BUDGET.
+func argName(k client.ResourceKind) string {
+	return strings.ToUpper(strings.Replace(k.Singular, " ", "-", -1))
+}
+
+func resourceDescribeCommand(k client.ResourceKind) *cobra.Command {
+	name := k.CommandName()
+	c := &cobra.Command{
+		Use:   fmt.Sprintf("describe [flags] [%s]", argName(k)),
+		Short: fmt.Sprintf("Display details of a %s", k.Singular),
+		Long:  fmt.Sprintf("Displays details of a %s in a namespace.", k.Singular),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := cmd.Flags().GetString("namespace")
+			if err != nil {
+				return err
+			}
+			return runResourceDescribe(k.Name, args[0], namespace)
+		},
+		Example: fmt.Sprintf(`k8ctl %[1]s describe --help
+k8ctl %[1]s describe --cluster nyc --namespace dev myapp-%[2]s
+k8ctl %[1]s describe -l nyc -n dev myapp-%[2]s`, name, strings.ToLower(argName(k))),
+	}
+	c.Flags().StringP("namespace", "n", "", "Namespace to report. (required)")
+	c.MarkFlagRequired("namespace")
+	return c
+}
+
+func resourceListCommand(k client.ResourceKind) *cobra.Command {
+	name := k.CommandName()
+	long := fmt.Sprintf("List will display a list of all %s in a namespace.", pluralize(k.Singular))
+	if len(k.Columns) > 0 {
+		long = fmt.Sprintf("%s\n\nColumns: %s", long, strings.Join(k.Columns, ", "))
+	}
+	c := &cobra.Command{
+		Use:   "list [flags]",
+		Short: fmt.Sprintf("List %s", pluralize(k.Singular)),
+		Long:  long,
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, format string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			format, err = cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+			return runResourceList(k.Name, namespace, format)
+		},
+		Example: fmt.Sprintf(`k8ctl %[1]s list --help
+k8ctl %[1]s list --cluster nyc --namespace dev
+k8ctl %[1]s list -l nyc -n dev
+k8ctl %[1]s list -l nyc -n dev --format json
+k8ctl %[1]s list -l nyc -n dev -f yaml`, name),
+	}
+	c.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+	c.Flags().StringP("namespace", "n", "", "Namespace to report. (required)")
+	c.MarkFlagRequired("namespace")
+	return c
+}
+
+func resourceRestartCommand(k client.ResourceKind) *cobra.Command {
+	name := k.CommandName()
+	c := &cobra.Command{
+		Use:   fmt.Sprintf("restart [flags] [%s]", argName(k)),
+		Short: fmt.Sprintf("Restart pods under a %s", k.Singular),
+		Long:  fmt.Sprintf("Restart will restart all pods under a %s in a namespace.", k.Singular),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, override string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if override, err = cmd.Flags().GetString("override-freeze"); err != nil {
+				return err
+			}
+			return runResourceRestart(k.Name, args[0], namespace, override)
+		},
+		Example: fmt.Sprintf(`k8ctl %[1]s restart --help
+k8ctl %[1]s restart --cluster nyc --namespace dev myapp-%[2]s
+k8ctl %[1]s restart -l nyc -n dev myapp-%[2]s`, name, strings.ToLower(argName(k))),
+	}
+	c.Flags().StringP("namespace", "n", "", "Namespace to report. (required)")
+	c.MarkFlagRequired("namespace")
+	addOverrideFreezeFlag(c)
+	return c
+}
+
+// Support functions to conduct the client call.
+
+func runResourceDescribe(kind string, name string, namespace string) error {
+	cl := newClient()
+	resp, err := cl.ResourceDescribe(kind, name, namespace)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
+
+func runResourceList(kind string, namespace string, format string) error {
+	cl := newClient()
+	resp, err := cl.ResourceList(kind, namespace, format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
+
+func runResourceRestart(kind string, name string, namespace string, override string) error {
+	note, err := checkFreeze(namespace, name, override)
+	if err != nil {
+		return err
+	}
+	cl := newClient()
+	resp, err := audited(&operation{Event: "restart", Namespace: namespace, Release: name, Memo: note}, func() (*client.Response, error) {
+		return cl.ResourceRestart(kind, name, namespace, note)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
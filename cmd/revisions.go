@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releasesSubCmdGet = &cobra.Command{
+		Use:     "get",
+		Short:   "Display the values, manifest or notes of a release",
+		Long:    "Top level command for displaying what a revision of a release contained.",
+		Example: `k8ctl releases get --help (for subcommands)`,
+	}
+
+	releasesSubCmdDiffRevisions = &cobra.Command{
+		Use:   "diff-revisions [flags] [RELEASE] [REVISION] [REVISION]",
+		Short: "Diff two revisions of a release",
+		Long: `Diff-revisions displays a unified diff of the rendered manifests, or the values, of two
+revisions of a release. Use it to see what a rollback will change.`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			release := args[0]
+			var from, to int
+			var content string
+			var noColor bool
+			var err error
+			if from, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid revision %q", args[1])
+			}
+			if to, err = strconv.Atoi(args[2]); err != nil {
+				return fmt.Errorf("invalid revision %q", args[2])
+			}
+			if content, err = cmd.Flags().GetString("content"); err != nil {
+				return err
+			}
+			if noColor, err = cmd.Flags().GetBool("no-color"); err != nil {
+				return err
+			}
+			return runDiffRevisions(release, from, to, content, noColor)
+		},
+		Example: `k8ctl releases diff-revisions --help
+k8ctl releases diff-revisions --cluster nyc myapp-prod 5 7
+k8ctl releases diff-revisions -l nyc --content values myapp-prod 5 7
+k8ctl releases diff-revisions -l nyc --no-color myapp-prod 5 7 > changes.diff`,
+	}
+)
+
+func init() {
+	releasesCmd.AddCommand(releasesSubCmdGet)
+	releasesCmd.AddCommand(releasesSubCmdDiffRevisions)
+	for _, content := range []string{client.ContentManifest, client.ContentNotes, client.ContentValues} {
+		releasesSubCmdGet.AddCommand(releaseContentCommand(content))
+	}
+
+	releasesSubCmdDiffRevisions.Flags().String("content", client.ContentManifest, "What to compare (manifest|values)")
+	releasesSubCmdDiffRevisions.Flags().Bool("no-color", false, "Do not colorize the diff")
+}
+
+// releaseContentCommand creates the get subcommand of a content of a release.
+func releaseContentCommand(content string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   fmt.Sprintf("%s [flags] [RELEASE]", content),
+		Short: fmt.Sprintf("Display the %s of a release", content),
+		Long:  fmt.Sprintf("Displays the %s of the current or a previous revision of a release.", content),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			release := args[0]
+			revision, err := cmd.Flags().GetInt("revision")
+			if err != nil {
+				return err
+			}
+			return runGetContent(release, content, revision)
+		},
+		Example: fmt.Sprintf(`k8ctl releases get %[1]s --help
+k8ctl releases get %[1]s --cluster nyc myapp-prod
+k8ctl releases get %[1]s -l nyc --revision 5 myapp-prod`, content),
+	}
+	c.Flags().IntP("revision", "r", 0, "A previous release revision (optional: default is the current)")
+	return c
+}
+
+// Support functions to conduct the client call.
+
+func runGetContent(release string, content string, revision int) error {
+	text, err := newClient().ReleaseContent(release, content, revision)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(stdout, text)
+	if !strings.HasSuffix(text, "\n") {
+		fmt.Fprintln(stdout)
+	}
+	return nil
+}
+
+func runDiffRevisions(release string, from int, to int, content string, noColor bool) error {
+	if content != client.ContentManifest && content != client.ContentValues {
+		return fmt.Errorf("invalid --content %q (manifest|values)", content)
+	}
+	cl := newClient()
+	a, err := cl.ReleaseContent(release, content, from)
+	if err != nil {
+		return err
+	}
+	b, err := cl.ReleaseContent(release, content, to)
+	if err != nil {
+		return err
+	}
+	diff := client.UnifiedDiff(a, b, fmt.Sprintf("%s revision %d %s", release, from, content),
+		fmt.Sprintf("%s revision %d %s", release, to, content))
+	if diff == "" {
+		fmt.Fprintf(stdout, "The %s of revisions %d and %d of %s are the same.\n", content, from, to, release)
+		return nil
+	}
+	printDiff(diff, useColor(noColor))
+	return nil
+}
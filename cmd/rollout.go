@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releasesSubCmdAbort = &cobra.Command{
+		Use:   "abort [flags] [RELEASE]",
+		Short: "Abort a canary or blue/green rollout",
+		Long:  "Abort returns all traffic to the stable version of a release and removes the new version.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			release := args[0]
+			var namespace, memo string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if memo, err = cmd.Flags().GetString("memo"); err != nil {
+				return err
+			}
+			return runAbort(release, namespace, memo)
+		},
+		Example: `k8ctl releases abort --help
+k8ctl releases abort --cluster nyc --namespace prod myapp-service
+k8ctl releases abort -l nyc -n prod -m "error rate up" myapp-service`,
+	}
+
+	releasesSubCmdPromoteCanary = &cobra.Command{
+		Use:   "promote-canary [flags] [RELEASE]",
+		Short: "Send all traffic to the new version of a rollout",
+		Long: `Promote-canary makes the new version of a paused canary or blue/green rollout the stable
+version and removes the old one. Use it when a canary was deployed with steps below 100.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			release := args[0]
+			var namespace, memo, override string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if memo, err = cmd.Flags().GetString("memo"); err != nil {
+				return err
+			}
+			if override, err = cmd.Flags().GetString("override-freeze"); err != nil {
+				return err
+			}
+			return runPromoteCanary(release, namespace, memo, override)
+		},
+		Example: `k8ctl releases promote-canary --help
+k8ctl releases promote-canary --cluster nyc --namespace prod myapp-service
+k8ctl releases promote-canary -l nyc -n prod -m "metrics look good" myapp-service`,
+	}
+)
+
+func init() {
+	releasesCmd.AddCommand(releasesSubCmdAbort)
+	releasesCmd.AddCommand(releasesSubCmdPromoteCanary)
+
+	releasesSubCmdAbort.Flags().StringP("namespace", "n", "", "Namespace of the release. (required)")
+	releasesSubCmdAbort.Flags().StringP("memo", "m", "", "Information to display in slack etc. (optional)")
+	releasesSubCmdAbort.MarkFlagRequired("namespace")
+
+	releasesSubCmdPromoteCanary.Flags().StringP("namespace", "n", "", "Namespace of the release. (required)")
+	releasesSubCmdPromoteCanary.Flags().StringP("memo", "m", "", "Information to display in slack etc. (optional)")
+	releasesSubCmdPromoteCanary.MarkFlagRequired("namespace")
+	addOverrideFreezeFlag(releasesSubCmdPromoteCanary)
+}
+
+// rolloutOptions are the deploy strategy flags.
+type rolloutOptions struct {
+	Strategy string        // canary, bluegreen or empty for all at once.
+	Steps    []int         // Percents of traffic of each canary step.
+	Pause    time.Duration // How long to watch each step before the next.
+	Timeout  time.Duration // How long to wait for each step to be healthy.
+}
+
+// addRolloutFlags adds the deploy strategy flags to a command.
+func addRolloutFlags(cmd *cobra.Command) {
+	cmd.Flags().String("strategy", "", "Deploy strategy (optional: canary|bluegreen; default is all at once)")
+	cmd.Flags().String("steps", "10,25,50,100", "Percents of traffic of each canary step")
+	cmd.Flags().Duration("pause", 5*time.Minute, "How long to watch the health of each step before the next")
+	cmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait for each step to become healthy")
+}
+
+// rolloutFlags reads the deploy strategy flags of a command.
+func rolloutFlags(cmd *cobra.Command) (*rolloutOptions, error) {
+	var opts rolloutOptions
+	var steps string
+	var err error
+	if opts.Strategy, err = cmd.Flags().GetString("strategy"); err != nil {
+		return nil, err
+	}
+	if steps, err = cmd.Flags().GetString("steps"); err != nil {
+		return nil, err
+	}
+	if opts.Pause, err = cmd.Flags().GetDuration("pause"); err != nil {
+		return nil, err
+	}
+	if opts.Timeout, err = cmd.Flags().GetDuration("timeout"); err != nil {
+		return nil, err
+	}
+	switch opts.Strategy {
+	case "", client.StrategyBlueGreen:
+	case client.StrategyCanary:
+		if opts.Steps, err = client.ParseSteps(steps); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown strategy %q (canary|bluegreen)", opts.Strategy)
+	}
+	return &opts, nil
+}
+
+// Support functions to conduct the client call.
+
+func runAbort(release string, namespace string, memo string) error {
+	cl := newClient()
+	resp, err := audited(&operation{Event: "rollback", Namespace: namespace, Release: release, Memo: memo},
+		func() (*client.Response, error) {
+			return cl.AbortRollout(release, namespace, memo)
+		})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
+
+func runPromoteCanary(release string, namespace string, memo string, override string) error {
+	note, err := checkFreeze(namespace, release, override)
+	if err != nil {
+		return err
+	}
+	cl := newClient()
+	r, err := cl.RolloutStatus(release, namespace)
+	if err != nil {
+		return err
+	}
+	memo = withOverride(memo, note)
+	resp, err := audited(&operation{Event: "deploy", Namespace: namespace, Release: release, Tag: r.NewTag, Memo: memo},
+		func() (*client.Response, error) {
+			return cl.PromoteRollout(release, namespace, memo)
+		})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
+
+// runRollout deploys a release step by step. Each step waits for the new version to be
+// healthy and watches it for the pause; a failure aborts the rollout.
+func runRollout(release string, tag string, namespace string, memo string, opts *rolloutOptions) error {
+	steps := opts.Steps
+	if opts.Strategy == client.StrategyBlueGreen {
+		steps = []int{0}
+	}
+	cl := newClient()
+	for i, weight := range steps {
+		op := &operation{Event: "rollout", Namespace: namespace, Release: release, Tag: tag, Memo: memo}
+		var err error
+		if i == 0 {
+			_, err = audited(op, func() (*client.Response, error) {
+				return cl.StartRollout(release, tag, namespace, opts.Strategy, weight, memo)
+			})
+		} else {
+			_, err = audited(op, func() (*client.Response, error) {
+				return cl.SetRolloutWeight(release, namespace, weight, memo)
+			})
+		}
+		if err != nil {
+			return abortRollout(cl, release, namespace, weight, err)
+		}
+		if opts.Strategy == client.StrategyBlueGreen {
+			fmt.Fprintf(stdout, "Deploying %s %s to namespace %s beside the stable version\n", release, tag, namespace)
+		} else {
+			fmt.Fprintf(stdout, "Step %d/%d: sending %d%% of traffic to %s %s\n", i+1, len(steps), weight, release, tag)
+		}
+		r, err := cl.WaitForRollout(release, namespace, weight, opts.Timeout)
+		if err != nil {
+			return abortRollout(cl, release, namespace, weight, err)
+		}
+		fmt.Fprintf(stdout, "Healthy: %d/%d replicas ready\n", r.ReadyReplicas, r.Replicas)
+		if weight == 100 {
+			break
+		}
+		if opts.Pause > 0 {
+			fmt.Fprintf(stdout, "Watching for %s\n", opts.Pause)
+			if _, err := cl.WatchRollout(release, namespace, opts.Pause); err != nil {
+				return abortRollout(cl, release, namespace, weight, err)
+			}
+		}
+	}
+
+	if opts.Strategy == client.StrategyCanary && steps[len(steps)-1] < 100 {
+		fmt.Fprintf(stdout, "Canary of %s paused at %d%%. Run \"k8ctl releases promote-canary\" or \"k8ctl releases abort\" to finish.\n",
+			release, steps[len(steps)-1])
+		return nil
+	}
+	resp, err := audited(&operation{Event: "deploy", Namespace: namespace, Release: release, Tag: tag, Memo: memo},
+		func() (*client.Response, error) {
+			return cl.PromoteRollout(release, namespace, memo)
+		})
+	if err != nil {
+		return abortRollout(cl, release, namespace, 100, err)
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
+
+// abortRollout rolls a failed rollout back to the stable version and returns the failure. It
+// wraps the cause and the error of the abort if it failed, and exits with the code of the cause.
+func abortRollout(cl client.Interface, release string, namespace string, weight int, cause error) error {
+	memo := fmt.Sprintf("Rollout failed at %d%%: %s. Aborted by %s.", weight, cause, currentUser())
+	fmt.Fprintf(stdout, "Rollout failed, returning all traffic to the stable version: %s\n", cause)
+	if _, err := audited(&operation{Event: "rollback", Namespace: namespace, Release: release, Memo: memo},
+		func() (*client.Response, error) {
+			return cl.AbortRollout(release, namespace, memo)
+		}); err != nil {
+		return &exitError{code: ExitCode(cause),
+			err: fmt.Errorf("rollout of %s failed (%w) and could not be aborted: %w", release, cause, err)}
+	}
+	return fmt.Errorf("rollout of %s failed and was aborted: %w", release, cause)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/composer22/k8ctl/client"
+)
+
+func TestAbortRolloutKeepsCause(t *testing.T) {
+	s := newTestServer(t)
+	// Set up the config and the output of the commands.
+	if _, _, err := runCommand(t, s, "version"); err != nil {
+		t.Fatalf("version: %s", err)
+	}
+	cause := fmt.Errorf("step 25%%: %w", client.ErrTimeout)
+	// There is no rollout to abort, so the abort fails too.
+	err := abortRollout(s.NewClient(), "myapp-prod", "prod", 25, cause)
+	if ExitCode(err) != ExitTimeout {
+		t.Errorf("exit %d (%v), want %d", ExitCode(err), err, ExitTimeout)
+	}
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || !strings.Contains(err.Error(), "could not be aborted") {
+		t.Errorf("error %v does not keep the error of the abort", err)
+	}
+}
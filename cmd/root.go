@@ -1,21 +1,52 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/composer22/k8ctl/client"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 // Used globally for all commands
 var (
-	cfgFile     string
-	cluster     string // Which server to use on what cluster?
-	format      string // text, json, or yaml.
-	bearerToken string // api token for the user access to the server
-	clusterUrl  string // endpoint to the server in the cluster of choice.
+	cfgFile      string
+	cluster      string            // Which server to use on what cluster?
+	format       string            // text, json, or yaml.
+	outputFormat string            // text or json, for errors.
+	bearerToken  string            // api token for the user access to the server
+	clusterUrl   string            // endpoint to the server in the cluster of choice.
+	discovery    *client.Discovery // capabilities of the server in the cluster, if known.
+	invokedCmd   *cobra.Command    // the command being run, for the audit log.
+	configData   []byte            // the config when given by an embedding program instead of a file.
+	verbosity    int               // -v count: the trace level of the HTTP requests.
+	debug        bool              // trace the HTTP requests with their bodies.
+	traceFile    string            // HAR file recording the HTTP requests.
+	httpTrace    *client.Trace     // traces the HTTP requests of the clients, if asked.
+	noCache      bool              // revalidate every cached response with the server.
+	audit        *client.AuditLog  // the audit log of the command, opened on first use.
+
+	stdin  io.Reader = os.Stdin  // input of the commands.
+	stdout io.Writer = os.Stdout // output of the commands.
+	stderr io.Writer = os.Stderr // warnings and progress of the commands.
+)
+
+const (
+	discoveryTTL      = 10 * time.Minute // How long the capabilities of a server are cached.
+	groupAnnotation   = "group"          // Marks the commands that only hold subcommands.
+	offlineAnnotation = "offline"        // Marks the commands that need neither the config nor a server.
 )
 
 // RootCmd represents the base command when called without any subcommands
@@ -23,67 +54,411 @@ var RootCmd = &cobra.Command{
 	Use:   "k8ctl",
 	Short: "Manage and deploy applications in a K8 cluster",
 	Long:  "A command line client for deploying and managing applications and releases in a cluster/namespace.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		invokedCmd = cmd
+		httpTrace = newTrace()
+		if cmd.Annotations[groupAnnotation] != "" {
+			return nil // Groups only print their help.
+		}
+		if cmd.Annotations[offlineAnnotation] != "" {
+			return nil // Ex: snapshot view reads a file only.
+		}
+		if err := initConfig(); err != nil {
+			return err
+		}
+		startTelemetry(cmd, args)
+		if err := checkSupported(cmd); err != nil {
+			finishTelemetry(err)
+			return err
+		}
+		return nil
+	},
+	SilenceErrors: true, // Printed by Execute.
+	SilenceUsage:  true,
 }
 
-// Execute adds all child commands to the root command sets flags appropriately.
+// Options configure the command tree for a program embedding it.
+type Options struct {
+	In            io.Reader     // Input of the commands (default: os.Stdin).
+	Out           io.Writer     // Output of the commands (default: os.Stdout).
+	Err           io.Writer     // Warnings, progress and errors (default: os.Stderr).
+	ConfigFile    string        // The config file, unless --config is given (default: $HOME/.k8ctl.yaml).
+	Config        io.Reader     // The YAML config, read unless a config file is given (optional).
+	ClientFactory ClientFactory // Creates the backend of a cluster (default: DefaultClientFactory).
+}
+
+// NewRootCommand configures the command tree with the options and returns its root, to be run
+// with Execute or mounted as a subcommand of another program. Errors are returned by the
+// commands instead of exiting. The tree is shared by the package: each call resets its flags and
+// the state of the previous run, so a program runs one command of a tree at a time.
+func NewRootCommand(opts *Options) (*cobra.Command, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	resetState(RootCmd)
+	stdin, stdout, stderr = opts.In, opts.Out, opts.Err
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	RootCmd.SetIn(stdin)
+	RootCmd.SetOut(stdout)
+	RootCmd.SetErr(stderr)
+	cfgFile, configData = opts.ConfigFile, nil
+	if opts.Config != nil {
+		b, err := ioutil.ReadAll(opts.Config)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the configuration: %s", err)
+		}
+		configData = b
+	}
+	SetClientFactory(opts.ClientFactory)
+	requireSubcommand(RootCmd)
+	traceCommandsOnce.Do(func() { traceCommands(RootCmd) })
+	return RootCmd, nil
+}
+
+// resetState restores the flags of the tree to their defaults and forgets the state of the
+// previous run: the cluster, its server and capabilities, the traces, the audit log and the
+// config.
+func resetState(root *cobra.Command) {
+	resetFlags(root)
+	cluster, clusterUrl, bearerToken = "", "", ""
+	discovery, invokedCmd, httpTrace, audit = nil, nil, nil, nil
+	tracer, commandSpan = nil, nil
+	viper.Reset()
+}
+
+// resetFlags restores the flags of a command and its subcommands to their defaults, and shows
+// the commands hidden by the capabilities of a server.
+func resetFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			var values []string
+			if s := strings.Trim(f.DefValue, "[]"); s != "" {
+				values = strings.Split(s, ",")
+			}
+			v.Replace(values)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	if _, ok := c.Annotations["resource"]; ok {
+		c.Hidden = false
+	}
+	for _, sub := range c.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// requireSubcommand makes the groups of the tree, which only hold subcommands, refuse an
+// unknown subcommand instead of printing their help and succeeding.
+func requireSubcommand(c *cobra.Command) {
+	for _, sub := range c.Commands() {
+		if sub.HasSubCommands() && !sub.Runnable() {
+			if sub.Annotations == nil {
+				sub.Annotations = make(map[string]string)
+			}
+			sub.Annotations[groupAnnotation] = "true"
+			sub.RunE = func(cmd *cobra.Command, args []string) error {
+				if len(args) > 0 {
+					return usageError(fmt.Errorf("unknown command %q for %q", args[0], cmd.CommandPath()))
+				}
+				return cmd.Help()
+			}
+		}
+		requireSubcommand(sub)
+	}
+}
+
+// Execute adds all child commands to the root command sets flags appropriately, and exits
+// with the code of the error of the command, if any.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
+	root, err := NewRootCommand(nil)
+	if err != nil {
+		PrintError(stderr, err)
+		os.Exit(ExitCode(err))
+	}
+	cmd, err := root.ExecuteC()
+	if httpTrace != nil && httpTrace.HAR != nil {
+		if herr := httpTrace.HAR.Err(); herr != nil {
+			fmt.Fprintln(stderr, "Warning: cannot write the trace file:", herr)
+		}
+	}
+	if err != nil {
+		if !root.PersistentFlags().Changed("output") {
+			outputFormat = outputFromArgs(os.Args[1:]) // Flags were not parsed.
+		}
+		PrintError(stderr, err)
+		if ExitCode(err) == ExitUsage && outputFormat != "json" {
+			fmt.Fprintf(stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
 	}
+	os.Exit(ExitCode(err))
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
 	RootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is $HOME/.k8ctl.yaml)")
 	RootCmd.PersistentFlags().StringVarP(&cluster, "cluster", "l", "", "Cluster to access")
+	RootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Format of errors (text|json)")
+	RootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v",
+		"Log the HTTP requests to stderr; -vv adds headers, -vvv bodies")
+	RootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Log the HTTP requests with headers and bodies (same as -vvv)")
+	RootCmd.PersistentFlags().StringVar(&traceFile, "trace-file", "", "Record the HTTP requests into a HAR file")
+	RootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Ask the server instead of using cached responses")
+	RootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
+	help := RootCmd.HelpFunc()
+	RootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		// Help does not run PersistentPreRunE: hide what the cached capabilities rule out.
+		hideUnsupported(cmd.Root(), cachedDiscovery())
+		help(cmd, args)
+	})
+}
+
+// newTrace returns the trace of the HTTP requests asked by -v, --debug and --trace-file, or
+// nil if none was.
+func newTrace() *client.Trace {
+	level := verbosity
+	if debug {
+		level = client.TraceBodies
+	}
+	if level == 0 && traceFile == "" {
+		return nil
+	}
+	t := &client.Trace{Level: level, Out: stderr}
+	if traceFile != "" {
+		t.HAR = client.NewHARLog(traceFile)
+	}
+	return t
 }
 
-func er(msg interface{}) {
-	fmt.Println("Error:", msg)
-	os.Exit(1)
+// outputFromArgs returns the value of --output in the arguments, or text.
+func outputFromArgs(args []string) string {
+	for i, a := range args {
+		switch {
+		case a == "--output" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(a, "--output="):
+			return strings.TrimPrefix(a, "--output=")
+		}
+	}
+	return "text"
 }
 
 // initConfig reads in config file.
-func initConfig() {
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Find home directory.
-		home, err := homedir.Dir()
-		if err != nil {
-			er(err)
+func initConfig() error {
+	switch {
+	case configData != nil && cfgFile == "":
+		// Use the config given by the embedding program.
+		viper.SetConfigType("yaml")
+		if err := viper.ReadConfig(bytes.NewReader(configData)); err != nil {
+			return configError(fmt.Errorf("cannot read the configuration: %s", err))
+		}
+	default:
+		if cfgFile != "" {
+			// Use config file from the flag.
+			viper.SetConfigFile(cfgFile)
+		} else {
+			// Find home directory.
+			home, err := homedir.Dir()
+			if err != nil {
+				return configError(err)
+			}
+			viper.AddConfigPath(home) // adding home directory as first search path.
+			viper.AddConfigPath(".")
+			viper.SetConfigName(".k8ctl") // name of config file (without extension).
+		}
+		// If a config file is found, read it in.
+		if err := viper.ReadInConfig(); err != nil {
+			return configError(fmt.Errorf("cannot find configuration file: %s", err))
 		}
-		viper.AddConfigPath(home) // adding home directory as first search path.
-		viper.AddConfigPath(".")
-		viper.SetConfigName(".k8ctl") // name of config file (without extension).
 	}
 	viper.AutomaticEnv()
-	// If a config file is found, read it in.
-
-	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("Cannot find configuration file.\n\nERR: %s\n", err.Error())
-		os.Exit(0)
-	}
 
 	// Retrieve the Cluster bearer token and url from the config based on the
 	// cluster param.
 	defaultCluster := viper.GetString("default_cluster")
 	if cluster == "" {
 		if defaultCluster == "" {
-			fmt.Println("Cluster name is mandatory.")
-			os.Exit(0)
+			return configError(errors.New("cluster name is mandatory; use --cluster or set default_cluster"))
 		}
 		cluster = defaultCluster
 	}
 
-	bearerToken = viper.GetString(fmt.Sprintf("clusters.%s.%s", cluster, "auth_token"))
-	clusterUrl = viper.GetString(fmt.Sprintf("clusters.%s.%s", cluster, "url"))
+	var err error
+	clusterUrl, bearerToken, err = clusterConfig(cluster)
+	return err
+}
 
-	// Cluster not found.
-	if clusterUrl == "" {
-		fmt.Printf("Cluster name %s not found\n", cluster)
-		os.Exit(0)
+// clusterConfig returns the url and bearer token of a cluster from the config.
+func clusterConfig(name string) (string, string, error) {
+	url := viper.GetString(fmt.Sprintf("clusters.%s.%s", name, "url"))
+	token := viper.GetString(fmt.Sprintf("clusters.%s.%s", name, "auth_token"))
+	if url == "" {
+		return "", "", configError(fmt.Errorf("cluster %s not found in the config", name))
+	}
+	return url, token, nil
+}
+
+// currentUser returns the name of the user running the command.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// stateDir returns the directory where the application keeps its cache and logs.
+func stateDir() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".k8ctl")
+}
+
+// ClientFactory returns the backend of a cluster given its name, the URL and bearer token of
+// its server from the config, and the capabilities of the server if known.
+type ClientFactory func(cluster string, url string, token string, d *client.Discovery) client.Interface
+
+// clientFactory creates the backend used by the commands.
+var clientFactory ClientFactory = DefaultClientFactory
+
+// DefaultClientFactory returns an HTTP client of the k8ctl-server of a cluster.
+func DefaultClientFactory(cluster string, url string, token string, d *client.Discovery) client.Interface {
+	cl := client.NewClient(url, token)
+	cl.Discovery = d
+	cl.Trace = httpTrace
+	cl.Tracer, cl.Span = tracer, commandSpan
+	cl.Cache = responseCache(cluster)
+	return cl
+}
+
+// SetClientFactory replaces how the commands reach a cluster, ex: to point them at a fake
+// server in tests or at another backend. nil restores DefaultClientFactory.
+func SetClientFactory(f ClientFactory) {
+	if f == nil {
+		f = DefaultClientFactory
+	}
+	clientFactory = f
+}
+
+// newClient returns a client for the selected cluster.
+func newClient() client.Interface {
+	return clientFactory(cluster, clusterUrl, bearerToken, discovery)
+}
+
+// clientFor returns a client for a cluster of the config.
+func clientFor(name string) (client.Interface, error) {
+	if name == cluster {
+		return newClient(), nil
+	}
+	url, token, err := clusterConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	return clientFactory(name, url, token, nil), nil
+}
+
+// discoveryCachePath returns the path of the capability cache for the selected cluster.
+func discoveryCachePath() string {
+	return filepath.Join(cacheDir(cluster), "discovery.json")
+}
+
+// loadDiscovery returns the capabilities of the server, from the cache if fresh enough.
+// Servers without a discovery route return nil, in which case nothing is restricted; that is
+// cached too, so that such servers are not asked again before the cache expires.
+func loadDiscovery() *client.Discovery {
+	cached, err := client.LoadDiscovery(discoveryCachePath())
+	if err == nil && cached.Fresh(discoveryTTL) {
+		if cached.Unavailable {
+			return nil
+		}
+		return cached
+	}
+	d, err := clientFactory(cluster, clusterUrl, bearerToken, nil).Discover()
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		saveDiscovery(&client.Discovery{Unavailable: true, FetchedAt: time.Now()})
+		return nil
+	}
+	if err != nil {
+		if cached != nil && cached.Unavailable {
+			return nil
+		}
+		return cached // Stale is better than nothing.
+	}
+	if skew := d.VersionSkew(); skew != "" {
+		fmt.Fprintln(stderr, "Warning:", skew)
+	}
+	saveDiscovery(d)
+	return d
+}
+
+// saveDiscovery caches the capabilities of the server. Failing to is reported but does not fail
+// the command.
+func saveDiscovery(d *client.Discovery) {
+	if err := d.Save(discoveryCachePath()); err != nil {
+		fmt.Fprintln(stderr, "Warning: cannot cache the capabilities of the server:", err)
+	}
+}
+
+// cachedDiscovery returns the cached capabilities of the server of the cluster given by the
+// flags or the config, however old, without asking the server; nil if there are none.
+func cachedDiscovery() *client.Discovery {
+	if cluster == "" {
+		if err := initConfig(); err != nil {
+			return nil
+		}
+	}
+	d, err := client.LoadDiscovery(discoveryCachePath())
+	if err != nil || d.Unavailable {
+		return nil
+	}
+	return d
+}
+
+// hideUnsupported hides the commands of the tree whose resource the server does not support.
+func hideUnsupported(root *cobra.Command, d *client.Discovery) {
+	if d == nil {
+		return
+	}
+	for _, c := range root.Commands() {
+		if resource, ok := c.Annotations["resource"]; ok && !d.Supports(resource, "") {
+			c.Hidden = true
+		}
+	}
+}
+
+// checkSupported hides the commands the server does not support and refuses to run
+// the selected one if it is among them. Commands are mapped to a server resource by the
+// "resource" annotation of their top level command; subcommand names are the verbs.
+func checkSupported(cmd *cobra.Command) error {
+	root := cmd.Root()
+	top, verb := cmd, ""
+	for top.Parent() != nil && top.Parent() != root {
+		verb = top.Name()
+		top = top.Parent()
+	}
+	if _, ok := top.Annotations["resource"]; !ok && cmd.Name() != "help" {
+		return nil
+	}
+	if discovery = loadDiscovery(); discovery == nil {
+		return nil
+	}
+	hideUnsupported(root, discovery)
+	if resource, ok := top.Annotations["resource"]; ok && !discovery.Supports(resource, verb) {
+		return usageError(fmt.Errorf("%s is not supported by the server in cluster %s", cmd.CommandPath(), cluster))
 	}
+	return nil
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/composer22/k8ctl/client/fake"
+	"github.com/mitchellh/go-homedir"
+)
+
+// newTestServer starts a fake server for the commands, with a home directory of its own for
+// the caches and the audit log.
+func newTestServer(t *testing.T) *fake.Server {
+	t.Helper()
+	s := fake.NewServer()
+	t.Cleanup(s.Close)
+	homedir.DisableCache = true
+	t.Setenv("HOME", t.TempDir())
+	return s
+}
+
+// runCommand runs k8ctl with args against the fake server, as cluster nyc by default. Cluster
+// down has no server.
+func runCommand(t *testing.T, s *fake.Server, args ...string) (string, string, error) {
+	t.Helper()
+	config := fmt.Sprintf(`default_cluster: nyc
+clusters:
+  nyc:
+    url: %s
+    auth_token: %s
+  down:
+    url: http://127.0.0.1:1
+    auth_token: %s
+`, s.URL, s.Token, s.Token)
+	var out, errOut bytes.Buffer
+	root, err := NewRootCommand(&Options{In: strings.NewReader(""), Out: &out, Err: &errOut,
+		Config: strings.NewReader(config)})
+	if err != nil {
+		t.Fatalf("NewRootCommand: %s", err)
+	}
+	root.SetArgs(args)
+	err = root.Execute()
+	return out.String(), errOut.String(), err
+}
+
+func TestNewRootCommandResetsCluster(t *testing.T) {
+	s := newTestServer(t)
+	if _, _, err := runCommand(t, s, "releases", "list", "-n", "dev", "--cluster", "down"); ExitCode(err) != ExitServer {
+		t.Fatalf("list on down: exit %d (%v), want %d", ExitCode(err), err, ExitServer)
+	}
+	if _, _, err := runCommand(t, s, "releases", "list", "-n", "dev"); err != nil {
+		t.Fatalf("list on the default cluster: %s", err)
+	}
+}
+
+func TestNewRootCommandResetsFlags(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) {
+		m.Deploy("myapp", "prod", "k8-1.0.0-1", "")
+		m.SetFreeze("prod", time.Now().Add(time.Hour), "release week", "ops")
+	})
+	deploy := []string{"releases", "deploy", "myapp", "-n", "prod", "-t", "k8-1.0.0-2", "-m", "fix"}
+	if _, _, err := runCommand(t, s, append(deploy, "--override-freeze", "hotfix")...); err != nil {
+		t.Fatalf("deploy with --override-freeze: %s", err)
+	}
+	if _, _, err := runCommand(t, s, deploy...); ExitCode(err) != ExitConflict {
+		t.Errorf("deploy during the freeze: exit %d (%v), want %d", ExitCode(err), err, ExitConflict)
+	}
+}
+
+func TestNewRootCommandResetsAuditLog(t *testing.T) {
+	s := newTestServer(t)
+	s.Update(func(m *fake.Model) { m.Deploy("myapp", "dev", "k8-1.0.0-1", "") })
+	deploy := []string{"releases", "deploy", "myapp", "-n", "dev", "-t", "k8-1.0.0-2", "-m", "fix"}
+	if _, _, err := runCommand(t, s, deploy...); err != nil {
+		t.Fatalf("deploy: %s", err)
+	}
+	// The next run opens the audit log of its own home directory.
+	t.Setenv("HOME", t.TempDir())
+	if _, _, err := runCommand(t, s, deploy...); err != nil {
+		t.Fatalf("deploy from another home: %s", err)
+	}
+	out, _, err := runCommand(t, s, "audit", "--format", "json")
+	if err != nil {
+		t.Fatalf("audit: %s", err)
+	}
+	if n := strings.Count(out, `"durationMs"`); n != 1 {
+		t.Errorf("audit log of the new home has %d entries, want 1:\n%s", n, out)
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsSubCmdDescribe = &cobra.Command{
+		Use:   "describe [flags] [SECRET]",
+		Short: "Display details of a secret",
+		Long: `Displays the keys, type, sizes and last modified time of a secret in a namespace.
+Values are redacted. --reveal will display the value of a single key if the server permits it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			var namespace, reveal string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if reveal, err = cmd.Flags().GetString("reveal"); err != nil {
+				return err
+			}
+			return runSecretsDescribe(name, namespace, reveal)
+		},
+		Example: `k8ctl secrets describe --help
+k8ctl secrets describe --cluster nyc --namespace dev myapp-secret
+k8ctl secrets describe -l nyc -n dev myapp-secret
+k8ctl secrets describe -l nyc -n dev --reveal DB_USER myapp-secret`,
+	}
+)
+
+func init() {
+	// The list command is generated from the resource registry; describe is added here
+	// because of its --reveal handling.
+	k, _ := client.LookupResource("secrets")
+	resourceCommand(*k).AddCommand(secretsSubCmdDescribe)
+
+	secretsSubCmdDescribe.Flags().StringP("namespace", "n", "", "Namespace to report. (required)")
+	secretsSubCmdDescribe.Flags().String("reveal", "", "Key whose value should be displayed, if permitted by the server (optional)")
+	secretsSubCmdDescribe.MarkFlagRequired("namespace")
+}
+
+// Support functions to conduct the client call.
+
+func runSecretsDescribe(name string, namespace string, reveal string) error {
+	cl := newClient()
+	call := func() (*client.Response, error) {
+		return cl.Secret(name, namespace, reveal)
+	}
+	var resp *client.Response
+	var err error
+	if reveal != "" {
+		// Every reveal attempt is recorded in the audit log with its request ID so it can be
+		// matched against the server logs.
+		resp, err = audited(&operation{Event: "reveal", Namespace: namespace, Release: name}, call)
+	} else {
+		resp, err = call()
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.Message)
+	return nil
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotCmd = &cobra.Command{
+		Use:   "snapshot [flags]",
+		Short: "Capture the state of a namespace for offline browsing",
+		Long: `Snapshot captures the list and describe output of every resource kind in a namespace, and the
+status and history of every release, into a timestamped archive. Browse it later with
+"snapshot view", without access to the cluster, or compare two with "snapshot diff".`,
+		Args: cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, file string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if file, err = cmd.Flags().GetString("out-file"); err != nil {
+				return err
+			}
+			return runSnapshot(namespace, file)
+		},
+		Example: `k8ctl snapshot --help
+k8ctl snapshot --cluster nyc --namespace prod
+k8ctl snapshot -l nyc -n prod -o snapshot.tar.gz`,
+	}
+
+	snapshotSubCmdView = &cobra.Command{
+		Use:   "view [flags] FILE [KIND|history [NAME]]",
+		Short: "Display the content of a snapshot",
+		Long: `View displays a snapshot as the commands displayed the namespace when it was taken. With only the
+file it summarizes the snapshot. A kind (releases, pods, deployments etc.) lists its resources,
+and a name describes one, or shows the status of a release. "history RELEASE" shows the history
+of a release.`,
+		Args:        cobra.RangeArgs(1, 3),
+		Annotations: map[string]string{offlineAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+			return runSnapshotView(args[0], args[1:], format)
+		},
+		Example: `k8ctl snapshot view --help
+k8ctl snapshot view snapshot.tar.gz
+k8ctl snapshot view snapshot.tar.gz releases
+k8ctl snapshot view snapshot.tar.gz releases myapp-prod
+k8ctl snapshot view snapshot.tar.gz history myapp-prod
+k8ctl snapshot view snapshot.tar.gz pods
+k8ctl snapshot view snapshot.tar.gz pods myapp-prod-5d8f7c9b6-x2x7q`,
+	}
+
+	snapshotSubCmdDiff = &cobra.Command{
+		Use:   "diff [flags] FILE-A FILE-B",
+		Short: "Compare two snapshots",
+		Long: `Diff lists the releases and resources added, removed or changed between two snapshots, with the
+fields that changed. Changes of age only are ignored. --full adds the unified diff of the
+status or describe output of each changed release or resource.`,
+		Args:        cobra.ExactArgs(2),
+		Annotations: map[string]string{offlineAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var full, noColor bool
+			var format string
+			var err error
+			if full, err = cmd.Flags().GetBool("full"); err != nil {
+				return err
+			}
+			if noColor, err = cmd.Flags().GetBool("no-color"); err != nil {
+				return err
+			}
+			if format, err = cmd.Flags().GetString("format"); err != nil {
+				return err
+			}
+			return runSnapshotDiff(args[0], args[1], full, noColor, format)
+		},
+		Example: `k8ctl snapshot diff --help
+k8ctl snapshot diff before.tar.gz after.tar.gz
+k8ctl snapshot diff --full before.tar.gz after.tar.gz
+k8ctl snapshot diff --format json before.tar.gz after.tar.gz`,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotSubCmdView)
+	snapshotCmd.AddCommand(snapshotSubCmdDiff)
+
+	snapshotCmd.Flags().StringP("namespace", "n", "", "Namespace to capture. (required)")
+	snapshotCmd.MarkFlagRequired("namespace")
+	snapshotCmd.Flags().StringP("out-file", "o", "",
+		"Archive to write (optional: default is snapshot-CLUSTER-NAMESPACE-TIME.tar.gz)")
+
+	snapshotSubCmdView.Flags().StringP("format", "f", "", "Format of the history (optional: json|yaml)")
+
+	snapshotSubCmdDiff.Flags().Bool("full", false, "Add the unified diff of each changed release or resource")
+	snapshotSubCmdDiff.Flags().Bool("no-color", false, "Do not colorize the diff")
+	snapshotSubCmdDiff.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+}
+
+// Support functions to conduct the client call.
+
+func runSnapshot(namespace string, file string) error {
+	var kinds []client.ResourceKind
+	d := loadDiscovery()
+	for _, k := range client.Resources() {
+		if d == nil || d.Supports(k.Name, string(client.VerbList)) {
+			kinds = append(kinds, k)
+		}
+	}
+	s, err := client.CaptureSnapshot(newClient(), cluster, namespace, kinds)
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		file = fmt.Sprintf("snapshot-%s-%s-%s.tar.gz", cluster, namespace, s.Manifest.Taken.Format("20060102T150405Z"))
+	}
+	if err := s.Write(file); err != nil {
+		return err
+	}
+	for _, e := range s.Manifest.Errors {
+		fmt.Fprintln(stderr, "Warning: not captured:", e)
+	}
+	fmt.Fprintf(stdout, "Snapshot of %s/%s written to %s: %d releases, %d resources.\n", cluster, namespace, file,
+		len(s.Releases()), snapshotResourceCount(s))
+	return nil
+}
+
+// snapshotResourceCount returns the number of kube resources in a snapshot.
+func snapshotResourceCount(s *client.Snapshot) int {
+	n := 0
+	for _, kind := range s.Manifest.Kinds {
+		n += len(s.Objects(kind))
+	}
+	return n
+}
+
+func runSnapshotView(file string, args []string, format string) error {
+	s, err := client.ReadSnapshot(file)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		printSnapshotSummary(s)
+		return nil
+	}
+	kind := args[0]
+	switch kind {
+	case "releases":
+		name := path.Join("releases", "list.txt")
+		if len(args) > 1 {
+			name = path.Join("releases", args[1], "status.txt")
+		}
+		return printSnapshotFile(s, name)
+	case "history":
+		if len(args) < 2 {
+			return usageError(fmt.Errorf("history requires a release name"))
+		}
+		entries, err := s.History(args[1])
+		if err != nil {
+			return err
+		}
+		return printHistory(entries, format)
+	}
+	k, ok := client.LookupResource(kind)
+	if !ok {
+		return usageError(fmt.Errorf("unknown resource %s", kind))
+	}
+	if len(args) > 1 {
+		return printSnapshotFile(s, path.Join(k.Name, "describe", args[1]+".txt"))
+	}
+	return printSnapshotFile(s, path.Join(k.Name, "list.txt"))
+}
+
+// printSnapshotFile prints captured output as the command that produced it did.
+func printSnapshotFile(s *client.Snapshot, name string) error {
+	b, err := s.File(name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, string(b))
+	return nil
+}
+
+// printSnapshotSummary prints what a snapshot holds.
+func printSnapshotSummary(s *client.Snapshot) {
+	m := &s.Manifest
+	fmt.Fprintf(stdout, "Cluster:   %s\nNamespace: %s\nTaken:     %s\nClient:    %s\n", m.Cluster, m.Namespace,
+		m.Taken.Local().Format(time.RFC3339), m.ClientVersion)
+	if m.ServerVersion != "" {
+		fmt.Fprintf(stdout, "Server:    %s\n", m.ServerVersion)
+	}
+	fmt.Fprintln(stdout)
+	rows := [][]string{{"releases", strconv.Itoa(len(s.Releases()))}}
+	for _, kind := range m.Kinds {
+		rows = append(rows, []string{kind, strconv.Itoa(len(s.Objects(kind)))})
+	}
+	printTable([]string{"KIND", "COUNT"}, rows)
+	if len(m.Errors) > 0 {
+		fmt.Fprintln(stdout, "\nNot captured:")
+		for _, e := range m.Errors {
+			fmt.Fprintln(stdout, "  "+e)
+		}
+	}
+}
+
+func runSnapshotDiff(fileA string, fileB string, full bool, noColor bool, format string) error {
+	a, err := client.ReadSnapshot(fileA)
+	if err != nil {
+		return err
+	}
+	b, err := client.ReadSnapshot(fileB)
+	if err != nil {
+		return err
+	}
+	changes := client.DiffSnapshots(a, b)
+	if format != "" {
+		if !full {
+			for i := range changes {
+				changes[i].Diff = ""
+			}
+		}
+		return printObject(changes, format)
+	}
+	fmt.Fprintf(stdout, "Comparing %s/%s at %s with %s/%s at %s\n\n", a.Manifest.Cluster, a.Manifest.Namespace,
+		a.Manifest.Taken.Local().Format(time.RFC3339), b.Manifest.Cluster, b.Manifest.Namespace,
+		b.Manifest.Taken.Local().Format(time.RFC3339))
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "No changes.")
+		return nil
+	}
+	var rows [][]string
+	for _, c := range changes {
+		rows = append(rows, []string{c.Kind, c.Name, c.Change, strings.Join(c.Details, ", ")})
+	}
+	printTable([]string{"KIND", "NAME", "CHANGE", "DETAILS"}, rows)
+	if full {
+		color := useColor(noColor)
+		for _, c := range changes {
+			if c.Diff != "" {
+				fmt.Fprintln(stdout)
+				printDiff(c.Diff, color)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	tracer      *client.Tracer // records the spans of the command, if telemetry is enabled.
+	commandSpan *client.Span   // the span of the running command, parent of its requests.
+
+	traceCommandsOnce sync.Once
+)
+
+// startTelemetry starts the span of a command when telemetry is enabled under telemetry in the
+// config or by the OTEL_* environment variables. A TRACEPARENT in the environment, ex: set by
+// a CI job, makes the command part of that trace.
+func startTelemetry(cmd *cobra.Command, args []string) {
+	var tc client.TelemetryConfig
+	if err := viper.UnmarshalKey("telemetry", &tc); err != nil {
+		fmt.Fprintln(stderr, "Warning: invalid telemetry in the config:", err)
+		return
+	}
+	tc.ApplyEnv()
+	t, err := client.NewTracer(&tc, stderr) // Keep the output of the command clean.
+	if err != nil {
+		fmt.Fprintln(stderr, "Warning:", err)
+		return
+	}
+	tracer = t
+	commandSpan = tracer.StartRemote(os.Getenv("TRACEPARENT"), cmd.CommandPath(), client.SpanKindInternal)
+	commandSpan.SetAttribute("k8ctl.cluster", cluster)
+	commandSpan.SetAttribute("k8ctl.args", strings.Join(args, " "))
+	commandSpan.SetAttribute("enduser.id", currentUser())
+	if f := cmd.Flags().Lookup("namespace"); f != nil && f.Value.String() != "" {
+		commandSpan.SetAttribute("k8ctl.namespace", f.Value.String())
+	}
+}
+
+// finishTelemetry ends the span of the command and exports the spans of the run. Failing to
+// export is reported but does not fail the command.
+func finishTelemetry(err error) {
+	if tracer == nil {
+		return
+	}
+	commandSpan.SetAttribute("process.exit.code", ExitCode(err))
+	commandSpan.Finish(err)
+	if ferr := tracer.Flush(); ferr != nil {
+		fmt.Fprintln(stderr, "Warning: cannot export the traces:", ferr)
+	}
+	tracer, commandSpan = nil, nil
+}
+
+// traceCommands makes the commands of the tree finish their span with their error when they
+// return.
+func traceCommands(c *cobra.Command) {
+	for _, sub := range c.Commands() {
+		if run := sub.RunE; run != nil {
+			sub.RunE = func(cmd *cobra.Command, args []string) error {
+				err := run(cmd, args)
+				finishTelemetry(err)
+				return err
+			}
+		}
+		traceCommands(sub)
+	}
+}
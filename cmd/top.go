@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/composer22/k8ctl/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topCmd = &cobra.Command{
+		Use:         "top",
+		Short:       "Display resource usage",
+		Long:        "Top level command for displaying the current CPU and memory usage in a namespace.",
+		Example:     `k8ctl top --help (for subcommands)`,
+		Annotations: map[string]string{"resource": "metrics"},
+	}
+
+	topSubCmdNamespace = &cobra.Command{
+		Use:   "namespace [flags]",
+		Short: "Display resource usage of a namespace",
+		Long:  "Displays the total CPU and memory usage of all pods in a namespace compared with their requests and limits.",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, format string
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if format, err = cmd.Flags().GetString("format"); err != nil {
+				return err
+			}
+			return runTopNamespace(namespace, format)
+		},
+		Example: `k8ctl top namespace --help
+k8ctl top namespace --cluster nyc --namespace dev
+k8ctl top namespace -l nyc -n dev --format json`,
+	}
+
+	topSubCmdPods = &cobra.Command{
+		Use:   "pods [flags]",
+		Short: "Display resource usage of pods",
+		Long:  "Displays the current CPU and memory usage of the pods in a namespace.",
+		Args:  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var namespace, format, sortBy string
+			var containers, requests bool
+			var err error
+			if namespace, err = cmd.Flags().GetString("namespace"); err != nil {
+				return err
+			}
+			if format, err = cmd.Flags().GetString("format"); err != nil {
+				return err
+			}
+			if sortBy, err = cmd.Flags().GetString("sort-by"); err != nil {
+				return err
+			}
+			if containers, err = cmd.Flags().GetBool("containers"); err != nil {
+				return err
+			}
+			if requests, err = cmd.Flags().GetBool("requests"); err != nil {
+				return err
+			}
+			return runTopPods(namespace, format, sortBy, containers, requests)
+		},
+		Example: `k8ctl top pods --help
+k8ctl top pods --cluster nyc --namespace dev
+k8ctl top pods -l nyc -n dev --sort-by memory
+k8ctl top pods -l nyc -n dev --containers --requests
+k8ctl top pods -l nyc -n dev -f json`,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(topCmd)
+	topCmd.AddCommand(topSubCmdNamespace)
+	topCmd.AddCommand(topSubCmdPods)
+
+	topSubCmdNamespace.Flags().StringP("namespace", "n", "", "Namespace to report. (required)")
+	topSubCmdNamespace.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+
+	topSubCmdPods.Flags().StringP("namespace", "n", "", "Namespace to report. (required)")
+	topSubCmdPods.Flags().StringP("format", "f", "", "Format (optional: json|yaml)")
+	topSubCmdPods.Flags().String("sort-by", "", "Sort by usage (optional: cpu|memory)")
+	topSubCmdPods.Flags().Bool("containers", false, "Display usage per container")
+	topSubCmdPods.Flags().Bool("requests", false, "Compare usage with requests and limits")
+
+	topSubCmdNamespace.MarkFlagRequired("namespace")
+	topSubCmdPods.MarkFlagRequired("namespace")
+}
+
+// Support functions to conduct the client call.
+
+func runTopNamespace(namespace string, format string) error {
+	cl := newClient()
+	ns, err := cl.TopNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	if format != "" {
+		return printObject(ns, format)
+	}
+	printTable([]string{"NAMESPACE", "PODS", "CPU", "CPU REQ", "CPU LIM", "MEMORY", "MEM REQ", "MEM LIM"},
+		[][]string{{ns.Namespace, fmt.Sprintf("%d", ns.Pods),
+			client.FormatCPU(ns.CPU), client.FormatCPU(ns.CPURequest), client.FormatCPU(ns.CPULimit),
+			client.FormatMemory(ns.Memory), client.FormatMemory(ns.MemoryRequest), client.FormatMemory(ns.MemoryLimit)}})
+	return nil
+}
+
+func runTopPods(namespace string, format string, sortBy string, containers bool, requests bool) error {
+	switch sortBy {
+	case "", "cpu", "memory":
+	default:
+		return fmt.Errorf("invalid --sort-by %q (cpu|memory)", sortBy)
+	}
+	cl := newClient()
+	pods, err := cl.TopPods(namespace)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		ti, tj := pods[i].Total(), pods[j].Total()
+		switch sortBy {
+		case "cpu":
+			return ti.CPU > tj.CPU
+		case "memory":
+			return ti.Memory > tj.Memory
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	if format != "" {
+		return printObject(pods, format)
+	}
+
+	headers := []string{"POD"}
+	if containers {
+		headers = append(headers, "CONTAINER")
+	}
+	headers = append(headers, "CPU")
+	if requests {
+		headers = append(headers, "CPU REQ", "CPU LIM")
+	}
+	headers = append(headers, "MEMORY")
+	if requests {
+		headers = append(headers, "MEM REQ", "MEM LIM")
+	}
+
+	var rows [][]string
+	for _, p := range pods {
+		usage := []client.ContainerMetrics{p.Total()}
+		if containers {
+			usage = p.Containers
+		}
+		for _, u := range usage {
+			row := []string{p.Name}
+			if containers {
+				row = append(row, u.Name)
+			}
+			row = append(row, client.FormatCPU(u.CPU))
+			if requests {
+				row = append(row, client.FormatCPU(u.CPURequest), client.FormatCPU(u.CPULimit))
+			}
+			row = append(row, client.FormatMemory(u.Memory))
+			if requests {
+				row = append(row, client.FormatMemory(u.MemoryRequest), client.FormatMemory(u.MemoryLimit))
+			}
+			rows = append(rows, row)
+		}
+	}
+	printTable(headers, rows)
+	return nil
+}
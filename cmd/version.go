@@ -12,14 +12,28 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Version of the application",
-	Long:  "Returns the version of the application",
-	Run: func(cmd *cobra.Command, args []string) {
-		result := client.NewClient("", "").Version()
-		fmt.Println(result)
+	Long:  "Returns the version of the application, and optionally of the server in the cluster",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		server, err := cmd.Flags().GetBool("server")
+		if err != nil {
+			return err
+		}
+		if !server {
+			fmt.Fprintln(stdout, client.NewClient("", "").Version())
+			return nil
+		}
+		result, err := newClient().ServerVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, result)
+		return nil
 	},
-	Example: `k8ctl -l nyc version`,
+	Example: `k8ctl -l nyc version
+k8ctl -l nyc version --server`,
 }
 
 func init() {
 	RootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolP("server", "s", false, "Include the version of the server")
 }